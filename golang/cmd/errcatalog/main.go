@@ -0,0 +1,67 @@
+// Command errcatalog writes the error catalog of the errors package
+// as JSON or Markdown for the client teams.
+//
+// Usage:
+//
+//	errcatalog [-format json|markdown] [-o file]
+//
+// The services with own error codes could use errors.WriteCatalogJSON
+// and errors.WriteCatalogMarkdown after registering their codes.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	errs "github.com/open-Q/common/golang/errors"
+	"github.com/pkg/errors"
+)
+
+// There are output formats.
+const (
+	formatJSON     = "json"
+	formatMarkdown = "markdown"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "errcatalog: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	var (
+		fs     = flag.NewFlagSet("errcatalog", flag.ContinueOnError)
+		format = fs.String("format", formatJSON, "the output format: json or markdown")
+		output = fs.String("o", "", "the output file, stdout by default")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var write func(io.Writer) error
+	switch *format {
+	case formatJSON:
+		write = errs.WriteCatalogJSON
+	case formatMarkdown, "md":
+		write = errs.WriteCatalogMarkdown
+	default:
+		return errors.Errorf("unknown format %s", *format)
+	}
+
+	if *output == "" {
+		return write(stdout)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return errors.Wrap(err, "could not create output file")
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return errors.Wrap(f.Close(), "could not close output file")
+}
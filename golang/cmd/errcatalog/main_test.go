@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_run(t *testing.T) {
+	t.Run("unknown format error", func(t *testing.T) {
+		err := run([]string{"-format", "yaml"}, ioutil.Discard)
+		require.EqualError(t, err, "unknown format yaml")
+	})
+	t.Run("json", func(t *testing.T) {
+		var b bytes.Buffer
+		require.NoError(t, run(nil, &b))
+		var items []struct {
+			Code      string `json:"code"`
+			Status    int32  `json:"status"`
+			Retryable bool   `json:"retryable"`
+		}
+		require.NoError(t, json.Unmarshal(b.Bytes(), &items))
+		require.NotEmpty(t, items)
+		found := false
+		for _, item := range items {
+			if item.Code == "STORAGE_TEMPORARY" {
+				found = true
+				require.Equal(t, int32(503), item.Status)
+				require.True(t, item.Retryable)
+			}
+		}
+		require.True(t, found)
+	})
+	t.Run("markdown file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "errcatalog")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		out := path.Join(dir, "errors.md")
+		require.NoError(t, run([]string{"-format", "markdown", "-o", out}, ioutil.Discard))
+		data, err := ioutil.ReadFile(out)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(data), "| Code | Kind | Status | Retryable | Message |\n"))
+		require.Contains(t, string(data), "| `NOT_FOUND` | not found | 404 | false | not found |\n")
+	})
+}
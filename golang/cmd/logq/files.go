@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const maxLineSize = 1 << 20
+
+// rotatedPattern matches the suffixes of the files rotated by logrotate, e.g. ".1" or ".2.gz".
+var rotatedPattern = regexp.MustCompile(`^\.(\d+)(\.gz)?$`)
+
+// rotatedFiles returns the rotated files of the log file from the oldest to the newest.
+func rotatedFiles(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not find rotated files of %s", path)
+	}
+
+	type rotated struct {
+		path string
+		n    int
+	}
+	files := make([]rotated, 0, len(matches))
+	for _, match := range matches {
+		m := rotatedPattern.FindStringSubmatch(strings.TrimPrefix(match, path))
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		files = append(files, rotated{path: match, n: n})
+	}
+	// the bigger number means the older file.
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].n > files[j].n
+	})
+
+	paths := make([]string, len(files))
+	for i := range files {
+		paths[i] = files[i].path
+	}
+	return paths, nil
+}
+
+// readFile calls fn for every line of the file.
+// Gzipped files are decompressed.
+func readFile(path string, fn func(line []byte)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "could not open %s", path)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return errors.Wrapf(err, "could not decompress %s", path)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+	for scanner.Scan() {
+		fn(scanner.Bytes())
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrapf(err, "could not read %s", path)
+	}
+	return nil
+}
+
+// followFile calls fn for every line of the file and waits for the new lines like tail -f.
+// The file is reopened when it's rotated and read from the beginning when it's truncated.
+// Blocks until the context is done.
+func followFile(ctx context.Context, path string, interval time.Duration, fn func(line []byte)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "could not open %s", path)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var (
+		r       = bufio.NewReader(f)
+		partial []byte
+		offset  int64
+	)
+	// drain reads all the complete lines and keeps the incomplete one.
+	drain := func() error {
+		for {
+			data, err := r.ReadBytes('\n')
+			offset += int64(len(data))
+			if err == io.EOF {
+				partial = append(partial, data...)
+				return nil
+			}
+			if err != nil {
+				return errors.Wrapf(err, "could not read %s", path)
+			}
+			if len(partial) != 0 {
+				data = append(partial, data...)
+				partial = nil
+			}
+			fn(bytes.TrimRight(data, "\r\n"))
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := drain(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := f.Stat()
+		if err != nil {
+			return errors.Wrapf(err, "could not stat %s", path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			// the file has been moved but not created yet.
+			continue
+		}
+		switch {
+		case !os.SameFile(current, info):
+			// the file has been rotated, read the rest of the old file first.
+			if err := drain(); err != nil {
+				return err
+			}
+			next, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			_ = f.Close()
+			f = next
+		case info.Size() < offset:
+			// the file has been truncated.
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return errors.Wrapf(err, "could not seek %s", path)
+			}
+		default:
+			continue
+		}
+		r.Reset(f)
+		partial = nil
+		offset = 0
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_rotatedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logq")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logPath := path.Join(dir, "app.log")
+	for _, name := range []string{"app.log", "app.log.1", "app.log.2.gz", "app.log.10.gz", "app.log.bak", "app.log.1.tmp"} {
+		err := ioutil.WriteFile(path.Join(dir, name), nil, 0666)
+		require.NoError(t, err)
+	}
+
+	files, err := rotatedFiles(logPath)
+	require.NoError(t, err)
+	require.Equal(t, []string{logPath + ".10.gz", logPath + ".2.gz", logPath + ".1"}, files)
+}
+
+func Test_readFile(t *testing.T) {
+	t.Run("open file error", func(t *testing.T) {
+		err := readFile("nonexists", func([]byte) {})
+		require.Error(t, err)
+		require.EqualError(t, err, "could not open nonexists: open nonexists: no such file or directory")
+	})
+	t.Run("gzipped file", func(t *testing.T) {
+		fPath := path.Join(os.TempDir(), "app.log.1.gz")
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, err := zw.Write([]byte("first\nsecond\n"))
+		require.NoError(t, err)
+		err = zw.Close()
+		require.NoError(t, err)
+		err = ioutil.WriteFile(fPath, buf.Bytes(), 0666)
+		require.NoError(t, err)
+		defer os.Remove(fPath)
+
+		var lines []string
+		err = readFile(fPath, func(line []byte) {
+			lines = append(lines, string(line))
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"first", "second"}, lines)
+	})
+}
+
+func Test_followFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logq")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	logPath := path.Join(dir, "app.log")
+	err = ioutil.WriteFile(logPath, []byte("first\n"), 0666)
+	require.NoError(t, err)
+
+	var (
+		mu    sync.Mutex
+		lines []string
+	)
+	waitLines := func(exp ...string) {
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(lines) == len(exp)
+		}, time.Second, 5*time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, exp, lines)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- followFile(ctx, logPath, 5*time.Millisecond, func(line []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			lines = append(lines, string(line))
+		})
+	}()
+	waitLines("first")
+
+	// an incomplete line is printed when it's completed.
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0666)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("sec"))
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = f.Write([]byte("ond\n"))
+	require.NoError(t, err)
+	err = f.Close()
+	require.NoError(t, err)
+	waitLines("first", "second")
+
+	// rotation.
+	err = os.Rename(logPath, logPath+".1")
+	require.NoError(t, err)
+	err = ioutil.WriteFile(logPath, []byte("third\n"), 0666)
+	require.NoError(t, err)
+	waitLines("first", "second", "third")
+
+	// truncation.
+	err = ioutil.WriteFile(logPath, []byte("4\n"), 0666)
+	require.NoError(t, err)
+	waitLines("first", "second", "third", "4")
+
+	cancel()
+	require.NoError(t, <-done)
+}
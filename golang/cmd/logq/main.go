@@ -0,0 +1,183 @@
+// Command logq queries and tails JSON log files written by log.NewFileLogger.
+//
+// Usage:
+//
+//	logq [flags] file...
+//
+// The rotated files of every file, e.g. service.log.1 and service.log.2.gz,
+// are read first. Lines which are not JSON log entries are skipped.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const followInterval = 200 * time.Millisecond
+
+// There are color modes.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// stringsFlag represents repeatable string flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-c
+		cancel()
+	}()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "logq: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	var (
+		fields stringsFlag
+		fs     = flag.NewFlagSet("logq", flag.ContinueOnError)
+		level  = fs.String("level", "trace", "the least severe level to show, e.g. warn shows warning, error, fatal and panic entries")
+		since  = fs.String("since", "", "show entries since the time: RFC3339 time, date or duration before now, e.g. 15m")
+		until  = fs.String("until", "", "show entries until the time: RFC3339 time, date or duration before now")
+		grep   = fs.String("grep", "", "show entries with the message matching the regular expression")
+		follow = fs.Bool("f", false, "wait for the new entries like tail -f")
+		color  = fs.String("color", colorAuto, "colorize the output: auto, always or never")
+		raw    = fs.Bool("json", false, "print the original JSON lines")
+		rotate = fs.Bool("rotated", true, "read the rotated files")
+	)
+	fs.Var(&fields, "field", "show entries with the field value, key=value, can be repeated")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: logq [flags] file...\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		fs.Usage()
+		return errors.New("log file is required")
+	}
+	if *follow && len(paths) > 1 {
+		return errors.New("follow mode supports a single file")
+	}
+
+	f, err := newFilter(*level, *since, *until, *grep, fields, time.Now())
+	if err != nil {
+		return err
+	}
+
+	p := printer{
+		w:   stdout,
+		raw: *raw,
+	}
+	switch *color {
+	case colorAlways:
+		p.color = true
+	case colorAuto:
+		p.color = isTerminal(stdout)
+	case colorNever:
+	default:
+		return errors.Errorf("unknown color mode %s", *color)
+	}
+
+	var printErr error
+	handle := func(line []byte) {
+		e, err := parseEntry(line)
+		if err != nil || !f.match(e) || printErr != nil {
+			return
+		}
+		printErr = p.print(e)
+	}
+
+	for i, path := range paths {
+		if *rotate {
+			rotated, err := rotatedFiles(path)
+			if err != nil {
+				return err
+			}
+			for _, r := range rotated {
+				if err := readFile(r, handle); err != nil {
+					return err
+				}
+			}
+		}
+		if *follow && i == len(paths)-1 {
+			if err := followFile(ctx, path, followInterval, handle); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := readFile(path, handle); err != nil {
+			return err
+		}
+	}
+	return printErr
+}
+
+func newFilter(level, since, until, grep string, fields []string, now time.Time) (*filter, error) {
+	var (
+		f   filter
+		err error
+	)
+	if f.level, err = logrus.ParseLevel(level); err != nil {
+		return nil, err
+	}
+	if f.since, err = parseTime(since, now); err != nil {
+		return nil, errors.Wrap(err, "invalid since value")
+	}
+	if f.until, err = parseTime(until, now); err != nil {
+		return nil, errors.Wrap(err, "invalid until value")
+	}
+	if grep != "" {
+		if f.message, err = regexp.Compile(grep); err != nil {
+			return nil, errors.Wrap(err, "invalid grep value")
+		}
+	}
+	if f.fields, err = parseFields(fields); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// isTerminal checks if the writer is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
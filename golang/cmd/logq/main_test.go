@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_run(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logq")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	logPath := path.Join(dir, "app.log")
+	err = ioutil.WriteFile(logPath+".1", []byte(`{"level":"error","msg":"could not find user","time":"2020-11-02T10:00:00Z","component":"storage","id":12}`+"\n"), 0666)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(logPath, []byte(`{"level":"info","msg":"started","time":"2020-11-02T11:00:00Z"}`+"\nnot json\n"), 0666)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name   string
+		args   []string
+		exp    string
+		expErr string
+	}{
+		{
+			name:   "log file is required error",
+			args:   []string{},
+			expErr: "log file is required",
+		},
+		{
+			name:   "follow several files error",
+			args:   []string{"-f", logPath, logPath},
+			expErr: "follow mode supports a single file",
+		},
+		{
+			name:   "invalid field filter error",
+			args:   []string{"-field", "id", logPath},
+			expErr: "invalid field filter id",
+		},
+		{
+			name:   "unknown color mode error",
+			args:   []string{"-color", "rainbow", logPath},
+			expErr: "unknown color mode rainbow",
+		},
+		{
+			name: "all the entries",
+			args: []string{logPath},
+			exp: "2020-11-02T10:00:00Z ERROR [storage] could not find user id=12\n" +
+				"2020-11-02T11:00:00Z INFO  started\n",
+		},
+		{
+			name: "without rotated files",
+			args: []string{"-rotated=false", logPath},
+			exp:  "2020-11-02T11:00:00Z INFO  started\n",
+		},
+		{
+			name: "filtered entries",
+			args: []string{"-level", "warn", "-field", "component=storage", "-grep", "user$", "-json", logPath},
+			exp:  `{"level":"error","msg":"could not find user","time":"2020-11-02T10:00:00Z","component":"storage","id":12}` + "\n",
+		},
+		{
+			name: "colored entries",
+			args: []string{"-color", "always", "-until", "2020-11-02T10:30:00Z", logPath},
+			exp:  "\x1b[90m2020-11-02T10:00:00Z\x1b[0m \x1b[31mERROR\x1b[0m [storage] could not find user \x1b[90mid=\x1b[0m12\n",
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := run(context.Background(), tc.args, &buf)
+			if tc.expErr != "" {
+				require.Error(t, err)
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, buf.String())
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// There are ANSI color codes.
+const (
+	colorRed    = 31
+	colorYellow = 33
+	colorCyan   = 36
+	colorGray   = 90
+)
+
+// componentKey is the field of the named sub-logger entries.
+const componentKey = "component"
+
+// printer prints the log entries.
+type printer struct {
+	w     io.Writer
+	color bool
+	// raw prints the original JSON lines.
+	raw bool
+}
+
+// print prints the entry as a line: time, level, component, message and sorted fields.
+func (p *printer) print(e *entry) error {
+	if p.raw {
+		_, err := fmt.Fprintf(p.w, "%s\n", e.Raw)
+		return err
+	}
+
+	var b bytes.Buffer
+	if !e.Time.IsZero() {
+		b.WriteString(p.paint(colorGray, e.Time.Format(time.RFC3339Nano)))
+		b.WriteByte(' ')
+	}
+	b.WriteString(p.paint(levelColor(e.Level), fmt.Sprintf("%-5.5s", strings.ToUpper(e.Level.String()))))
+	if component, ok := e.Fields[componentKey]; ok {
+		fmt.Fprintf(&b, " [%v]", component)
+	}
+	b.WriteByte(' ')
+	b.WriteString(e.Message)
+
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		if k != componentKey {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteByte(' ')
+		b.WriteString(p.paint(colorGray, k+"="))
+		b.WriteString(formatValue(e.Fields[k]))
+	}
+	b.WriteByte('\n')
+
+	_, err := p.w.Write(b.Bytes())
+	return err
+}
+
+func (p *printer) paint(color int, s string) string {
+	if !p.color {
+		return s
+	}
+	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", color, s)
+}
+
+func levelColor(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel:
+		return colorRed
+	case logrus.WarnLevel:
+		return colorYellow
+	case logrus.InfoLevel:
+		return colorCyan
+	default:
+		return colorGray
+	}
+}
+
+// formatValue formats the field value as JSON, strings with spaces or quotes are quoted.
+func formatValue(v interface{}) string {
+	s, ok := v.(string)
+	if !ok {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n\\") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// entry represents parsed JSON log line.
+type entry struct {
+	Time    time.Time
+	Level   logrus.Level
+	Message string
+	Fields  map[string]interface{}
+	Raw     []byte
+}
+
+// There are the keys of the JSON and ECS log formats.
+var (
+	timeKeys    = []string{"time", "@timestamp"}
+	levelKeys   = []string{"level", "log.level"}
+	messageKeys = []string{"msg", "message"}
+)
+
+// parseEntry parses the log line written by the JSON or ECS formatter.
+func parseEntry(line []byte) (*entry, error) {
+	// the numbers are kept as is, so the large ids are not formatted as floats.
+	var fields map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return nil, errors.Wrap(err, "could not parse log line")
+	}
+
+	e := entry{
+		Level:  logrus.InfoLevel,
+		Fields: fields,
+		Raw:    line,
+	}
+	if v, ok := popString(fields, timeKeys); ok {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse log time")
+		}
+		e.Time = t
+	}
+	if v, ok := popString(fields, levelKeys); ok {
+		level, err := logrus.ParseLevel(v)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse log level")
+		}
+		e.Level = level
+	}
+	e.Message, _ = popString(fields, messageKeys)
+	return &e, nil
+}
+
+// popString removes the first found key from the fields and returns its string value.
+func popString(fields map[string]interface{}, keys []string) (string, bool) {
+	for _, key := range keys {
+		v, ok := fields[key]
+		if !ok {
+			continue
+		}
+		delete(fields, key)
+		s, ok := v.(string)
+		return s, ok
+	}
+	return "", false
+}
+
+// filter represents log entries filter.
+type filter struct {
+	// level is the least severe level to match.
+	level   logrus.Level
+	since   time.Time
+	until   time.Time
+	fields  map[string]string
+	message *regexp.Regexp
+}
+
+// match checks if the entry matches all the filter conditions.
+func (f *filter) match(e *entry) bool {
+	if e.Level > f.level {
+		return false
+	}
+	if !f.since.IsZero() && e.Time.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && e.Time.After(f.until) {
+		return false
+	}
+	for key, value := range f.fields {
+		v, ok := e.Fields[key]
+		if !ok || fmt.Sprint(v) != value {
+			return false
+		}
+	}
+	if f.message != nil && !f.message.MatchString(e.Message) {
+		return false
+	}
+	return true
+}
+
+// parseTime parses the time filter value: RFC3339 time, a date,
+// or a duration before now, e.g. "15m" means 15 minutes ago.
+func parseTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.Errorf("invalid time %s", value)
+}
+
+// parseFields parses the field filters in the "key=value" format.
+func parseFields(values []string) (map[string]string, error) {
+	fields := make(map[string]string, len(values))
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("invalid field filter %s", value)
+		}
+		fields[parts[0]] = parts[1]
+	}
+	return fields, nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseEntry(t *testing.T) {
+	tt := []struct {
+		name   string
+		line   string
+		exp    *entry
+		expErr string
+	}{
+		{
+			name: "json",
+			line: `{"level":"warning","msg":"hello","time":"2020-11-02T10:00:00Z","id":1}`,
+			exp: &entry{
+				Time:    time.Date(2020, 11, 2, 10, 0, 0, 0, time.UTC),
+				Level:   logrus.WarnLevel,
+				Message: "hello",
+				Fields:  map[string]interface{}{"id": json.Number("1")},
+			},
+		},
+		{
+			name: "large number",
+			line: `{"msg":"hello","user_id":123456789}`,
+			exp: &entry{
+				Level:   logrus.InfoLevel,
+				Message: "hello",
+				Fields:  map[string]interface{}{"user_id": json.Number("123456789")},
+			},
+		},
+		{
+			name: "ecs",
+			line: `{"log.level":"error","message":"hello","@timestamp":"2020-11-02T10:00:00Z","ecs.version":"1.6.0"}`,
+			exp: &entry{
+				Time:    time.Date(2020, 11, 2, 10, 0, 0, 0, time.UTC),
+				Level:   logrus.ErrorLevel,
+				Message: "hello",
+				Fields:  map[string]interface{}{"ecs.version": "1.6.0"},
+			},
+		},
+		{
+			name:   "not json error",
+			line:   `level=info msg=hello`,
+			expErr: "could not parse log line: invalid character 'l' looking for beginning of value",
+		},
+		{
+			name:   "invalid level error",
+			line:   `{"level":"loud"}`,
+			expErr: `could not parse log level: not a valid logrus Level: "loud"`,
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := parseEntry([]byte(tc.line))
+			if tc.expErr != "" {
+				require.Error(t, err)
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			tc.exp.Raw = []byte(tc.line)
+			require.Equal(t, tc.exp, e)
+		})
+	}
+}
+
+func TestFilter_match(t *testing.T) {
+	e := entry{
+		Time:    time.Date(2020, 11, 2, 10, 0, 0, 0, time.UTC),
+		Level:   logrus.WarnLevel,
+		Message: "could not find user",
+		Fields:  map[string]interface{}{"id": json.Number("12"), "user_id": json.Number("123456789"), "component": "storage"},
+	}
+	tt := []struct {
+		name   string
+		filter filter
+		exp    bool
+	}{
+		{
+			name:   "level matched",
+			filter: filter{level: logrus.WarnLevel},
+			exp:    true,
+		},
+		{
+			name:   "level not matched",
+			filter: filter{level: logrus.ErrorLevel},
+		},
+		{
+			name: "time range matched",
+			filter: filter{
+				level: logrus.TraceLevel,
+				since: e.Time.Add(-time.Minute),
+				until: e.Time,
+			},
+			exp: true,
+		},
+		{
+			name: "time range not matched",
+			filter: filter{
+				level: logrus.TraceLevel,
+				since: e.Time.Add(time.Second),
+			},
+		},
+		{
+			name: "fields matched",
+			filter: filter{
+				level:  logrus.TraceLevel,
+				fields: map[string]string{"id": "12", "component": "storage"},
+			},
+			exp: true,
+		},
+		{
+			name: "large number field matched",
+			filter: filter{
+				level:  logrus.TraceLevel,
+				fields: map[string]string{"user_id": "123456789"},
+			},
+			exp: true,
+		},
+		{
+			name: "fields not matched",
+			filter: filter{
+				level:  logrus.TraceLevel,
+				fields: map[string]string{"id": "13"},
+			},
+		},
+		{
+			name: "message matched",
+			filter: filter{
+				level:   logrus.TraceLevel,
+				message: regexp.MustCompile("find (user|order)"),
+			},
+			exp: true,
+		},
+		{
+			name: "message not matched",
+			filter: filter{
+				level:   logrus.TraceLevel,
+				message: regexp.MustCompile("^find"),
+			},
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, tc.filter.match(&e))
+		})
+	}
+}
+
+func Test_parseTime(t *testing.T) {
+	now := time.Date(2020, 11, 2, 10, 0, 0, 0, time.UTC)
+	tt := []struct {
+		name   string
+		value  string
+		exp    time.Time
+		expErr string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:  "duration",
+			value: "15m",
+			exp:   now.Add(-15 * time.Minute),
+		},
+		{
+			name:  "time",
+			value: "2020-11-01T08:30:00Z",
+			exp:   time.Date(2020, 11, 1, 8, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "date",
+			value: "2020-11-01",
+			exp:   time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "invalid time error",
+			value:  "yesterday",
+			expErr: "invalid time yesterday",
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := parseTime(tc.value, now)
+			if tc.expErr != "" {
+				require.Error(t, err)
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, v)
+		})
+	}
+}
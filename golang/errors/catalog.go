@@ -0,0 +1,365 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Code represents stable machine-readable error code, e.g. USER_NOT_FOUND.
+type Code string
+
+// Definition represents the error catalog entry.
+type Definition struct {
+	// Code is the unique error code in upper snake case.
+	Code Code
+	// Kind is the sentinel error of the taxonomy, e.g. ErrNotFound.
+	// The coded errors match the kind with errors.Is.
+	Kind error
+	// Message is the default error message.
+	Message string
+	// Status is HTTP status, which is used as go-micro error code as well.
+	// The status of the kind is used if it is not set.
+	Status int32
+	// Retryable reports whether the failed operation could be retried.
+	Retryable bool
+}
+
+// There are codes of the common errors.
+const (
+	CodeUnknown            Code = "UNKNOWN"
+	CodeValidation         Code = "VALIDATION_FAILED"
+	CodeNotFound           Code = "NOT_FOUND"
+	CodeAlreadyExists      Code = "ALREADY_EXISTS"
+	CodePermissionDenied   Code = "PERMISSION_DENIED"
+	CodeUnauthenticated    Code = "UNAUTHENTICATED"
+	CodePreconditionFailed Code = "PRECONDITION_FAILED"
+	CodeRateLimited        Code = "RATE_LIMITED"
+	CodeUnavailable        Code = "UNAVAILABLE"
+	CodeStorageConvert     Code = "STORAGE_CONVERT"
+	CodeStorageInsert      Code = "STORAGE_INSERT"
+	CodeStorageDelete      Code = "STORAGE_DELETE"
+	CodeStorageUpdate      Code = "STORAGE_UPDATE"
+	CodeStorageFind        Code = "STORAGE_FIND"
+	CodeStorageUnknown     Code = "STORAGE_UNKNOWN"
+	CodeStorageDuplicate   Code = "STORAGE_DUPLICATE"
+	CodeStorageNotFound    Code = "STORAGE_NOT_FOUND"
+	CodeStorageTemporary   Code = "STORAGE_TEMPORARY"
+)
+
+var codePattern = regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`)
+
+// catalog represents the registry of the error definitions.
+type catalog struct {
+	mu   sync.RWMutex
+	defs map[Code]Definition
+	// kinds contains the codes of the common errors ordered from the specific kinds to the common ones.
+	kinds []Code
+}
+
+var defaultCatalog = newCatalog()
+
+func newCatalog() *catalog {
+	c := &catalog{
+		defs: make(map[Code]Definition),
+	}
+	for _, def := range []Definition{
+		{Code: CodeStorageDuplicate, Kind: ErrStorageDuplicate, Message: "duplicate value"},
+		{Code: CodeStorageNotFound, Kind: ErrStorageNotFound, Message: "value not found"},
+		{Code: CodeStorageTemporary, Kind: ErrStorageTemporary, Message: "temporary storage failure", Retryable: true},
+		{Code: CodeStorageConvert, Kind: ErrStorageConvert, Message: "could not convert value"},
+		{Code: CodeStorageInsert, Kind: ErrStorageInsert, Message: "could not insert value"},
+		{Code: CodeStorageDelete, Kind: ErrStorageDelete, Message: "could not delete value"},
+		{Code: CodeStorageUpdate, Kind: ErrStorageUpdate, Message: "could not update value"},
+		{Code: CodeStorageFind, Kind: ErrStorageFind, Message: "could not find value"},
+		{Code: CodeStorageUnknown, Kind: ErrStorageUnknown, Message: "unknown storage error"},
+		{Code: CodeValidation, Kind: ErrValidation, Message: "validation failed"},
+		{Code: CodeNotFound, Kind: ErrNotFound, Message: "not found"},
+		{Code: CodeAlreadyExists, Kind: ErrAlreadyExists, Message: "already exists"},
+		{Code: CodePermissionDenied, Kind: ErrPermissionDenied, Message: "permission denied"},
+		{Code: CodeUnauthenticated, Kind: ErrUnauthenticated, Message: "unauthenticated"},
+		{Code: CodePreconditionFailed, Kind: ErrPreconditionFailed, Message: "precondition failed"},
+		{Code: CodeRateLimited, Kind: ErrRateLimited, Message: "rate limited", Retryable: true},
+		{Code: CodeUnavailable, Kind: ErrUnavailable, Message: "service unavailable", Retryable: true},
+	} {
+		def = c.withDefaults(def)
+		c.defs[def.Code] = def
+		c.kinds = append(c.kinds, def.Code)
+	}
+	c.defs[CodeUnknown] = Definition{
+		Code:    CodeUnknown,
+		Message: "unknown error",
+		Status:  http.StatusInternalServerError,
+	}
+	return c
+}
+
+func (c *catalog) withDefaults(def Definition) Definition {
+	if def.Status != 0 {
+		return def
+	}
+	def.Status = http.StatusInternalServerError
+	if def.Kind == nil {
+		return def
+	}
+	for i := range microMappings {
+		if errors.Is(def.Kind, microMappings[i].sentinel) {
+			def.Status = microMappings[i].code
+			break
+		}
+	}
+	return def
+}
+
+func (c *catalog) register(def Definition) (Definition, error) {
+	if !codePattern.MatchString(string(def.Code)) {
+		return def, errors.Errorf("invalid error code %q", def.Code)
+	}
+	if def.Message == "" {
+		return def, errors.Errorf("empty message of error code %s", def.Code)
+	}
+	def = c.withDefaults(def)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.defs[def.Code]; ok {
+		return def, errors.Errorf("error code %s is already registered", def.Code)
+	}
+	c.defs[def.Code] = def
+	return def, nil
+}
+
+func (c *catalog) lookup(code Code) (Definition, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	def, ok := c.defs[code]
+	return def, ok
+}
+
+func (c *catalog) definitions() []Definition {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	defs := make([]Definition, 0, len(c.defs))
+	for _, def := range c.defs {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Code < defs[j].Code
+	})
+	return defs
+}
+
+// codeOf returns the code of the first common error kind matching the error.
+func (c *catalog) codeOf(err error) Code {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, code := range c.kinds {
+		if errors.Is(err, c.defs[code].Kind) {
+			return code
+		}
+	}
+	return CodeUnknown
+}
+
+// Register registers the error definition in the catalog.
+// The code must be unique and written in upper snake case, the message is required.
+func Register(def Definition) (Code, error) {
+	def, err := defaultCatalog.register(def)
+	if err != nil {
+		return "", err
+	}
+	return def.Code, nil
+}
+
+// MustRegister registers the error definition in the catalog and panics on error.
+// It is designed for the package level variables:
+//
+//	var CodeUserNotFound = errors.MustRegister(errors.Definition{
+//		Code:    "USER_NOT_FOUND",
+//		Kind:    errors.ErrNotFound,
+//		Message: "user not found",
+//	})
+func MustRegister(def Definition) Code {
+	code, err := Register(def)
+	if err != nil {
+		panic(err)
+	}
+	return code
+}
+
+// Lookup returns the registered error definition of the code.
+func Lookup(code Code) (Definition, bool) {
+	return defaultCatalog.lookup(code)
+}
+
+// Definitions returns all registered error definitions sorted by code.
+func Definitions() []Definition {
+	return defaultCatalog.definitions()
+}
+
+// CodeError represents the error with the registered code.
+// The error matches the kind of the code definition and the optional cause error.
+type CodeError struct {
+	err   error
+	cause error
+	stack *stack
+	def   Definition
+}
+
+// Error returns error as a string value.
+func (e CodeError) Error() string {
+	return chainError(e.err, e.cause)
+}
+
+// Unwrap returns the cause error if any, otherwise the low level of the provided error.
+func (e CodeError) Unwrap() error {
+	return chainUnwrap(e.err, e.cause)
+}
+
+// Is checks if the kind of the code definition matches the target.
+// The cause error is checked by errors.Is through Unwrap.
+func (e CodeError) Is(target error) bool {
+	return errors.Is(e.err, target)
+}
+
+// StackTrace returns the stack trace of the error creation compatible with github.com/pkg/errors.
+// The stack trace is nil if the capture is turned off by SetStackCapture.
+func (e CodeError) StackTrace() errors.StackTrace {
+	return e.stack.StackTrace()
+}
+
+// Format formats the error. %+v prints the whole chain including the cause details and the stack trace.
+func (e CodeError) Format(s fmt.State, verb rune) {
+	chainFormat(s, verb, e.err, e.cause, e.stack)
+}
+
+// Code returns the error code.
+func (e CodeError) Code() Code {
+	return e.def.Code
+}
+
+// Definition returns the error code definition.
+func (e CodeError) Definition() Definition {
+	return e.def
+}
+
+// NewCodeError creates new CodeError instance of the registered code.
+// The message replaces the default message of the code definition if set.
+// The unknown code is replaced by CodeUnknown.
+func NewCodeError(code Code, message string) CodeError {
+	return wrapCodeError(nil, code, message)
+}
+
+// WrapCodeError creates new CodeError instance of the registered code with the cause error.
+// The message replaces the default message of the code definition if set.
+// The unknown code is replaced by CodeUnknown.
+func WrapCodeError(err error, code Code, message string) CodeError {
+	return wrapCodeError(err, code, message)
+}
+
+// CodeOf returns the code of the error: the code of CodeError if any,
+// otherwise the code of the common error kind, e.g. NOT_FOUND for NotFoundError.
+// CodeUnknown is returned for other errors and an empty code for nil error.
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var codeErr CodeError
+	if errors.As(err, &codeErr) {
+		return codeErr.def.Code
+	}
+	return defaultCatalog.codeOf(err)
+}
+
+// DefinitionOf returns the code definition of the error.
+func DefinitionOf(err error) Definition {
+	var codeErr CodeError
+	if errors.As(err, &codeErr) {
+		return codeErr.def
+	}
+	def, _ := defaultCatalog.lookup(CodeOf(err))
+	return def
+}
+
+// WriteCatalogJSON writes the registered error definitions to w as JSON array.
+func WriteCatalogJSON(w io.Writer) error {
+	defs := Definitions()
+	items := make([]catalogItem, len(defs))
+	for i := range defs {
+		items[i] = newCatalogItem(defs[i])
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return errors.Wrap(enc.Encode(items), "could not write catalog")
+}
+
+// WriteCatalogMarkdown writes the registered error definitions to w as Markdown table.
+func WriteCatalogMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("| Code | Kind | Status | Retryable | Message |\n")
+	b.WriteString("|------|------|--------|-----------|---------|\n")
+	for _, def := range Definitions() {
+		item := newCatalogItem(def)
+		fmt.Fprintf(&b, "| `%s` | %s | %d | %t | %s |\n",
+			item.Code, item.Kind, item.Status, item.Retryable, strings.ReplaceAll(item.Message, "|", "\\|"))
+	}
+	_, err := io.WriteString(w, b.String())
+	return errors.Wrap(err, "could not write catalog")
+}
+
+// catalogItem represents the exported error definition.
+type catalogItem struct {
+	Code      Code   `json:"code"`
+	Kind      string `json:"kind,omitempty"`
+	Message   string `json:"message"`
+	Status    int32  `json:"status"`
+	Retryable bool   `json:"retryable"`
+}
+
+func newCatalogItem(def Definition) catalogItem {
+	item := catalogItem{
+		Code:      def.Code,
+		Message:   def.Message,
+		Status:    def.Status,
+		Retryable: def.Retryable,
+	}
+	if def.Kind != nil {
+		item.Kind = def.Kind.Error()
+	}
+	return item
+}
+
+func wrapCodeError(cause error, code Code, msg string) CodeError {
+	def, ok := defaultCatalog.lookup(code)
+	if !ok {
+		def, _ = defaultCatalog.lookup(CodeUnknown)
+	}
+	// the default message is not repeated if the kind message already ends with it,
+	// the custom message is dropped only if it is the kind message itself.
+	custom := msg != ""
+	if !custom {
+		msg = def.Message
+	}
+	var err error
+	switch {
+	case def.Kind != nil && (msg == def.Kind.Error() || !custom && strings.HasSuffix(def.Kind.Error(), msg)):
+		err = fmt.Errorf("%w", def.Kind)
+	case def.Kind != nil:
+		err = fmt.Errorf("%w: %s", def.Kind, msg)
+	default:
+		err = errors.New(msg)
+	}
+	return CodeError{
+		err:   err,
+		cause: cause,
+		stack: callers(1),
+		def:   def,
+	}
+}
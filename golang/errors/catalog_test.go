@@ -0,0 +1,186 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	microerrors "github.com/micro/go-micro/v2/errors"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var testCodeUserNotFound = MustRegister(Definition{
+	Code:    "TEST_USER_NOT_FOUND",
+	Kind:    ErrNotFound,
+	Message: "user not found",
+})
+
+func Test_Register(t *testing.T) {
+	tt := []struct {
+		name   string
+		def    Definition
+		expErr string
+	}{
+		{
+			name:   "invalid code error",
+			def:    Definition{Code: "user_not_found", Message: "user not found"},
+			expErr: `invalid error code "user_not_found"`,
+		},
+		{
+			name:   "empty message error",
+			def:    Definition{Code: "TEST_EMPTY_MESSAGE"},
+			expErr: "empty message of error code TEST_EMPTY_MESSAGE",
+		},
+		{
+			name:   "already registered error",
+			def:    Definition{Code: testCodeUserNotFound, Message: "user not found"},
+			expErr: "error code TEST_USER_NOT_FOUND is already registered",
+		},
+		{
+			name: "all ok",
+			def:  Definition{Code: "TEST_USER_LOCKED", Kind: ErrPreconditionFailed, Message: "user is locked"},
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			code, err := Register(tc.def)
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.def.Code, code)
+		})
+	}
+	require.Panics(t, func() {
+		MustRegister(Definition{Code: testCodeUserNotFound, Message: "user not found"})
+	})
+
+	def, ok := Lookup("TEST_USER_LOCKED")
+	require.True(t, ok)
+	require.Equal(t, int32(412), def.Status)
+	_, ok = Lookup("TEST_MISSING")
+	require.False(t, ok)
+}
+
+func Test_NewCodeError(t *testing.T) {
+	err := NewCodeError(testCodeUserNotFound, "")
+	require.EqualError(t, err, "not found: user not found")
+	require.Equal(t, testCodeUserNotFound, err.Code())
+	require.Equal(t, int32(404), err.Definition().Status)
+	require.True(t, IsNotFound(err))
+
+	err = NewCodeError(testCodeUserNotFound, "user 42")
+	require.EqualError(t, err, "not found: user 42")
+
+	err = NewCodeError(CodeStorageDuplicate, "")
+	require.EqualError(t, err, ErrStorageDuplicate.Error())
+
+	err = NewCodeError(CodeStorageDuplicate, "value")
+	require.EqualError(t, err, ErrStorageDuplicate.Error()+": value")
+
+	err = NewCodeError(CodeStorageDuplicate, ErrStorageDuplicate.Error())
+	require.EqualError(t, err, ErrStorageDuplicate.Error())
+
+	err = NewCodeError("TEST_MISSING", "")
+	require.Equal(t, CodeUnknown, err.Code())
+	require.EqualError(t, err, "unknown error")
+
+	cause := errors.New("some cause")
+	err = WrapCodeError(cause, testCodeUserNotFound, "")
+	require.True(t, errors.Is(err, cause))
+	require.True(t, errors.Is(err, ErrNotFound))
+	require.EqualError(t, err, "not found: user not found: some cause")
+	require.True(t, strings.HasPrefix(fmt.Sprintf("%+v", err), "not found: user not found: some cause\n"))
+}
+
+func Test_CodeOf(t *testing.T) {
+	tt := []struct {
+		name         string
+		err          error
+		expCode      Code
+		expRetryable bool
+	}{
+		{
+			name:    "nil",
+			err:     nil,
+			expCode: "",
+		},
+		{
+			name:    "code error",
+			err:     pkgerrors.Wrap(NewCodeError(testCodeUserNotFound, ""), "wrapped"),
+			expCode: testCodeUserNotFound,
+		},
+		{
+			name:    "domain error",
+			err:     NewPermissionDeniedError("user"),
+			expCode: CodePermissionDenied,
+		},
+		{
+			name:    "specific storage error",
+			err:     WrapStorageNotFoundError(errors.New("no documents"), ""),
+			expCode: CodeStorageNotFound,
+		},
+		{
+			name:         "temporary storage error",
+			err:          NewStorageTemporaryError("user"),
+			expCode:      CodeStorageTemporary,
+			expRetryable: true,
+		},
+		{
+			name:    "common storage error",
+			err:     NewStorageFindError("user"),
+			expCode: CodeStorageFind,
+		},
+		{
+			name:    "unknown error",
+			err:     errors.New("some error"),
+			expCode: CodeUnknown,
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expCode, CodeOf(tc.err))
+			require.Equal(t, tc.expRetryable, DefinitionOf(tc.err).Retryable)
+		})
+	}
+}
+
+func Test_CodeErrorMicro(t *testing.T) {
+	err := ToMicroError("user", NewCodeError(testCodeUserNotFound, "user 42"))
+	microErr, ok := err.(*microerrors.Error)
+	require.True(t, ok)
+	require.Equal(t, int32(404), microErr.Code)
+	require.Equal(t, string(testCodeUserNotFound), microErr.Status)
+
+	err = FromMicroError(microerrors.Parse(err.Error()))
+	require.EqualError(t, err, "not found: user 42")
+	require.Equal(t, testCodeUserNotFound, CodeOf(err))
+	require.True(t, IsNotFound(err))
+}
+
+func Test_WriteCatalog(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteCatalogJSON(&b))
+	var items []map[string]interface{}
+	require.NoError(t, json.Unmarshal(b.Bytes(), &items))
+	require.Equal(t, len(Definitions()), len(items))
+	require.Contains(t, items, map[string]interface{}{
+		"code":      string(testCodeUserNotFound),
+		"kind":      "not found",
+		"message":   "user not found",
+		"status":    float64(404),
+		"retryable": false,
+	})
+
+	b.Reset()
+	require.NoError(t, WriteCatalogMarkdown(&b))
+	require.Contains(t, b.String(), "| `TEST_USER_NOT_FOUND` | not found | 404 | false | user not found |\n")
+	require.Contains(t, b.String(), "| `UNKNOWN` |  | 500 | false | unknown error |\n")
+}
@@ -0,0 +1,68 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// chainError returns the sentinel based error message followed by the cause message.
+func chainError(err, cause error) string {
+	if cause != nil {
+		return err.Error() + ": " + cause.Error()
+	}
+	return err.Error()
+}
+
+// chainUnwrap returns the cause error if any, otherwise the low level of the sentinel based error.
+func chainUnwrap(err, cause error) error {
+	if cause != nil {
+		return cause
+	}
+	return errors.Unwrap(err)
+}
+
+// chainFormat formats the error chain. %+v prints the cause details and the stack trace.
+func chainFormat(s fmt.State, verb rune, err, cause error, st *stack) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = io.WriteString(s, err.Error())
+			if cause != nil {
+				_, _ = fmt.Fprintf(s, ": %+v", cause)
+			}
+			st.format(s)
+			return
+		}
+		fallthrough
+	case 's':
+		_, _ = io.WriteString(s, chainError(err, cause))
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", chainError(err, cause))
+	}
+}
+
+// formatWrapped formats the wrapping error the same way as the provided error.
+func formatWrapped(s fmt.State, verb rune, err error) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = fmt.Fprintf(s, "%+v", err)
+			return
+		}
+		fallthrough
+	case 's':
+		_, _ = io.WriteString(s, err.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", err.Error())
+	}
+}
+
+// withMessage wraps the sentinel error with the optional message.
+func withMessage(err error, msg string) error {
+	if msg != "" {
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return fmt.Errorf("%w", err)
+}
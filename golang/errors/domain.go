@@ -0,0 +1,263 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// There are common domain errors.
+var (
+	ErrValidation         = errors.New("validation failed")
+	ErrNotFound           = errors.New("not found")
+	ErrAlreadyExists      = errors.New("already exists")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrUnauthenticated    = errors.New("unauthenticated")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrRateLimited        = errors.New("rate limited")
+	ErrUnavailable        = errors.New("unavailable")
+)
+
+// DomainError represents common domain error structure.
+// The error matches its sentinel error and the optional cause error.
+type DomainError struct {
+	err   error
+	cause error
+	stack *stack
+}
+
+// FieldViolation represents the validation failure of the single field.
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+type (
+	// ValidationError represents invalid input error with per-field violations.
+	ValidationError struct {
+		*DomainError
+		Violations []FieldViolation
+	}
+	// NotFoundError represents missing entity error.
+	NotFoundError struct {
+		*DomainError
+	}
+	// AlreadyExistsError represents existing entity error.
+	AlreadyExistsError struct {
+		*DomainError
+	}
+	// PermissionDeniedError represents forbidden action error.
+	PermissionDeniedError struct {
+		*DomainError
+	}
+	// UnauthenticatedError represents missing or invalid credentials error.
+	UnauthenticatedError struct {
+		*DomainError
+	}
+	// PreconditionFailedError represents the error of the action,
+	// which could not be done in the current state, e.g. version mismatch.
+	PreconditionFailedError struct {
+		*DomainError
+	}
+	// RateLimitedError represents too many requests error.
+	RateLimitedError struct {
+		*DomainError
+	}
+	// UnavailableError represents temporary unavailable dependency error.
+	UnavailableError struct {
+		*DomainError
+	}
+)
+
+// Error returns error as a string value.
+func (e DomainError) Error() string {
+	return chainError(e.err, e.cause)
+}
+
+// Unwrap returns the cause error if any, otherwise the low level of the provided error.
+func (e DomainError) Unwrap() error {
+	return chainUnwrap(e.err, e.cause)
+}
+
+// Is checks if the sentinel error of the provided error matches the target.
+// The cause error is checked by errors.Is through Unwrap.
+func (e DomainError) Is(target error) bool {
+	return errors.Is(e.err, target)
+}
+
+// StackTrace returns the stack trace of the error creation compatible with github.com/pkg/errors.
+// The stack trace is nil if the capture is turned off by SetStackCapture.
+func (e DomainError) StackTrace() errors.StackTrace {
+	return e.stack.StackTrace()
+}
+
+// Format formats the error. %+v prints the whole chain including the cause details and the stack trace.
+func (e DomainError) Format(s fmt.State, verb rune) {
+	chainFormat(s, verb, e.err, e.cause, e.stack)
+}
+
+// String returns field violation as a string value.
+func (v FieldViolation) String() string {
+	return v.Field + ": " + v.Description
+}
+
+// NewValidationError creates new ValidationError instance.
+// The violations are added to the error message.
+func NewValidationError(message string, violations ...FieldViolation) ValidationError {
+	return ValidationError{
+		DomainError: wrapDomainError(nil, validationMessage(message, violations), ErrValidation),
+		Violations:  violations,
+	}
+}
+
+// WrapValidationError creates new ValidationError instance with the cause error.
+// The message is optional.
+func WrapValidationError(err error, message string, violations ...FieldViolation) ValidationError {
+	return ValidationError{
+		DomainError: wrapDomainError(err, validationMessage(message, violations), ErrValidation),
+		Violations:  violations,
+	}
+}
+
+// NewNotFoundError creates new NotFoundError instance.
+func NewNotFoundError(message string) NotFoundError {
+	return NotFoundError{wrapDomainError(nil, message, ErrNotFound)}
+}
+
+// WrapNotFoundError creates new NotFoundError instance with the cause error.
+// The message is optional.
+func WrapNotFoundError(err error, message string) NotFoundError {
+	return NotFoundError{wrapDomainError(err, message, ErrNotFound)}
+}
+
+// NewAlreadyExistsError creates new AlreadyExistsError instance.
+func NewAlreadyExistsError(message string) AlreadyExistsError {
+	return AlreadyExistsError{wrapDomainError(nil, message, ErrAlreadyExists)}
+}
+
+// WrapAlreadyExistsError creates new AlreadyExistsError instance with the cause error.
+// The message is optional.
+func WrapAlreadyExistsError(err error, message string) AlreadyExistsError {
+	return AlreadyExistsError{wrapDomainError(err, message, ErrAlreadyExists)}
+}
+
+// NewPermissionDeniedError creates new PermissionDeniedError instance.
+func NewPermissionDeniedError(message string) PermissionDeniedError {
+	return PermissionDeniedError{wrapDomainError(nil, message, ErrPermissionDenied)}
+}
+
+// WrapPermissionDeniedError creates new PermissionDeniedError instance with the cause error.
+// The message is optional.
+func WrapPermissionDeniedError(err error, message string) PermissionDeniedError {
+	return PermissionDeniedError{wrapDomainError(err, message, ErrPermissionDenied)}
+}
+
+// NewUnauthenticatedError creates new UnauthenticatedError instance.
+func NewUnauthenticatedError(message string) UnauthenticatedError {
+	return UnauthenticatedError{wrapDomainError(nil, message, ErrUnauthenticated)}
+}
+
+// WrapUnauthenticatedError creates new UnauthenticatedError instance with the cause error.
+// The message is optional.
+func WrapUnauthenticatedError(err error, message string) UnauthenticatedError {
+	return UnauthenticatedError{wrapDomainError(err, message, ErrUnauthenticated)}
+}
+
+// NewPreconditionFailedError creates new PreconditionFailedError instance.
+func NewPreconditionFailedError(message string) PreconditionFailedError {
+	return PreconditionFailedError{wrapDomainError(nil, message, ErrPreconditionFailed)}
+}
+
+// WrapPreconditionFailedError creates new PreconditionFailedError instance with the cause error.
+// The message is optional.
+func WrapPreconditionFailedError(err error, message string) PreconditionFailedError {
+	return PreconditionFailedError{wrapDomainError(err, message, ErrPreconditionFailed)}
+}
+
+// NewRateLimitedError creates new RateLimitedError instance.
+func NewRateLimitedError(message string) RateLimitedError {
+	return RateLimitedError{wrapDomainError(nil, message, ErrRateLimited)}
+}
+
+// WrapRateLimitedError creates new RateLimitedError instance with the cause error.
+// The message is optional.
+func WrapRateLimitedError(err error, message string) RateLimitedError {
+	return RateLimitedError{wrapDomainError(err, message, ErrRateLimited)}
+}
+
+// NewUnavailableError creates new UnavailableError instance.
+func NewUnavailableError(message string) UnavailableError {
+	return UnavailableError{wrapDomainError(nil, message, ErrUnavailable)}
+}
+
+// WrapUnavailableError creates new UnavailableError instance with the cause error.
+// The message is optional.
+func WrapUnavailableError(err error, message string) UnavailableError {
+	return UnavailableError{wrapDomainError(err, message, ErrUnavailable)}
+}
+
+// IsValidation checks if the error is the validation error.
+func IsValidation(err error) bool {
+	return errors.Is(err, ErrValidation)
+}
+
+// IsNotFound checks if the error is the not found error.
+// StorageFindError is recognized as well.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, ErrStorageFind)
+}
+
+// IsAlreadyExists checks if the error is the already exists error.
+// The storage duplicate value error is recognized as well.
+func IsAlreadyExists(err error) bool {
+	return errors.Is(err, ErrAlreadyExists) || errors.Is(err, ErrStorageDuplicate)
+}
+
+// IsPermissionDenied checks if the error is the permission denied error.
+func IsPermissionDenied(err error) bool {
+	return errors.Is(err, ErrPermissionDenied)
+}
+
+// IsUnauthenticated checks if the error is the unauthenticated error.
+func IsUnauthenticated(err error) bool {
+	return errors.Is(err, ErrUnauthenticated)
+}
+
+// IsPreconditionFailed checks if the error is the precondition failed error.
+func IsPreconditionFailed(err error) bool {
+	return errors.Is(err, ErrPreconditionFailed)
+}
+
+// IsRateLimited checks if the error is the rate limited error.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// IsUnavailable checks if the error is the unavailable error.
+// The storage temporary failure error is recognized as well.
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrUnavailable) || errors.Is(err, ErrStorageTemporary)
+}
+
+func wrapDomainError(cause error, msg string, err error) *DomainError {
+	return &DomainError{
+		err:   withMessage(err, msg),
+		cause: cause,
+		stack: callers(1),
+	}
+}
+
+func validationMessage(msg string, violations []FieldViolation) string {
+	if len(violations) == 0 {
+		return msg
+	}
+	items := make([]string, len(violations))
+	for i := range violations {
+		items[i] = violations[i].String()
+	}
+	if msg == "" {
+		return strings.Join(items, "; ")
+	}
+	return msg + ": " + strings.Join(items, "; ")
+}
@@ -0,0 +1,193 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewDomainError(t *testing.T) {
+	tt := []struct {
+		name  string
+		err   error
+		expIs error
+		expAs interface{}
+	}{
+		{
+			name:  "not found",
+			err:   NewNotFoundError("some error"),
+			expIs: ErrNotFound,
+			expAs: &NotFoundError{},
+		},
+		{
+			name:  "already exists",
+			err:   NewAlreadyExistsError("some error"),
+			expIs: ErrAlreadyExists,
+			expAs: &AlreadyExistsError{},
+		},
+		{
+			name:  "permission denied",
+			err:   NewPermissionDeniedError("some error"),
+			expIs: ErrPermissionDenied,
+			expAs: &PermissionDeniedError{},
+		},
+		{
+			name:  "unauthenticated",
+			err:   NewUnauthenticatedError("some error"),
+			expIs: ErrUnauthenticated,
+			expAs: &UnauthenticatedError{},
+		},
+		{
+			name:  "precondition failed",
+			err:   NewPreconditionFailedError("some error"),
+			expIs: ErrPreconditionFailed,
+			expAs: &PreconditionFailedError{},
+		},
+		{
+			name:  "rate limited",
+			err:   NewRateLimitedError("some error"),
+			expIs: ErrRateLimited,
+			expAs: &RateLimitedError{},
+		},
+		{
+			name:  "unavailable",
+			err:   NewUnavailableError("some error"),
+			expIs: ErrUnavailable,
+			expAs: &UnavailableError{},
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			require.Error(t, tc.err)
+			require.True(t, errors.Is(tc.err, tc.expIs))
+			require.True(t, errors.As(tc.err, tc.expAs))
+			require.EqualError(t, tc.err, fmt.Sprintf("%v: some error", tc.expIs))
+
+			wrapped := pkgerrors.Wrap(tc.err, "could not handle request")
+			require.True(t, errors.Is(wrapped, tc.expIs))
+			require.True(t, errors.As(wrapped, tc.expAs))
+		})
+	}
+}
+
+func Test_WrapDomainError(t *testing.T) {
+	cause := errors.New("some cause")
+	err := WrapUnavailableError(cause, "user service")
+	require.True(t, errors.Is(err, ErrUnavailable))
+	require.True(t, errors.Is(err, cause))
+	var e UnavailableError
+	require.True(t, errors.As(err, &e))
+	require.EqualError(t, err, "unavailable: user service: some cause")
+	require.True(t, strings.HasPrefix(fmt.Sprintf("%+v", err), "unavailable: user service: some cause\n"))
+
+	err = WrapUnavailableError(cause, "")
+	require.EqualError(t, err, "unavailable: some cause")
+}
+
+func Test_NewValidationError(t *testing.T) {
+	t.Run("violations", func(t *testing.T) {
+		violations := []FieldViolation{
+			{Field: "email", Description: "must be valid"},
+			{Field: "name", Description: "is required"},
+		}
+		err := NewValidationError("user", violations...)
+		require.True(t, errors.Is(err, ErrValidation))
+		require.EqualError(t, err, "validation failed: user: email: must be valid; name: is required")
+
+		var e ValidationError
+		require.True(t, errors.As(pkgerrors.Wrap(err, "could not create user"), &e))
+		require.Equal(t, violations, e.Violations)
+	})
+	t.Run("violations without message", func(t *testing.T) {
+		err := NewValidationError("", FieldViolation{Field: "email", Description: "must be valid"})
+		require.EqualError(t, err, "validation failed: email: must be valid")
+	})
+	t.Run("cause", func(t *testing.T) {
+		cause := errors.New("invalid character")
+		err := WrapValidationError(cause, "request body")
+		require.True(t, errors.Is(err, ErrValidation))
+		require.True(t, errors.Is(err, cause))
+		require.EqualError(t, err, "validation failed: request body: invalid character")
+		require.Empty(t, err.Violations)
+	})
+}
+
+func Test_IsDomainError(t *testing.T) {
+	tt := []struct {
+		name  string
+		is    func(error) bool
+		match []error
+		other []error
+	}{
+		{
+			name:  "validation",
+			is:    IsValidation,
+			match: []error{NewValidationError("user")},
+			other: []error{NewStorageConvertError("user"), NewNotFoundError("user")},
+		},
+		{
+			name: "not found",
+			is:   IsNotFound,
+			match: []error{
+				NewNotFoundError("user"),
+				NewStorageFindError("user"),
+				WrapStorageNotFoundError(errors.New("no documents"), ""),
+			},
+			other: []error{NewStorageInsertError("user"), errors.New("not found")},
+		},
+		{
+			name:  "already exists",
+			is:    IsAlreadyExists,
+			match: []error{NewAlreadyExistsError("user"), NewStorageDuplicateError("user")},
+			other: []error{NewStorageInsertError("user")},
+		},
+		{
+			name:  "permission denied",
+			is:    IsPermissionDenied,
+			match: []error{NewPermissionDeniedError("user")},
+			other: []error{NewUnauthenticatedError("user")},
+		},
+		{
+			name:  "unauthenticated",
+			is:    IsUnauthenticated,
+			match: []error{NewUnauthenticatedError("user")},
+			other: []error{NewPermissionDeniedError("user")},
+		},
+		{
+			name:  "precondition failed",
+			is:    IsPreconditionFailed,
+			match: []error{NewPreconditionFailedError("user")},
+			other: []error{NewValidationError("user")},
+		},
+		{
+			name:  "rate limited",
+			is:    IsRateLimited,
+			match: []error{NewRateLimitedError("user")},
+			other: []error{NewUnavailableError("user")},
+		},
+		{
+			name:  "unavailable",
+			is:    IsUnavailable,
+			match: []error{NewUnavailableError("user"), NewStorageTemporaryError("user")},
+			other: []error{NewStorageUnknownError("user"), NewRateLimitedError("user")},
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			require.False(t, tc.is(nil))
+			for _, err := range tc.match {
+				require.True(t, tc.is(err), err.Error())
+				require.True(t, tc.is(pkgerrors.Wrap(err, "wrapped")), err.Error())
+			}
+			for _, err := range tc.other {
+				require.False(t, tc.is(err), err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package errors
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Fields represents key/value context of the error, e.g. collection or document ID.
+type Fields map[string]interface{}
+
+// fieldsError represents the error with key/value fields.
+type fieldsError struct {
+	err    error
+	fields Fields
+}
+
+// Error returns error as a string value. The fields are not the part of the message.
+func (e *fieldsError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the provided error.
+func (e *fieldsError) Unwrap() error {
+	return e.err
+}
+
+// Format formats the error the same way as the provided error.
+func (e *fieldsError) Format(s fmt.State, verb rune) {
+	formatWrapped(s, verb, e.err)
+}
+
+// WithFields adds the fields to the error. The fields accumulate as the error is wrapped,
+// the outer fields override the inner ones with the same key.
+// WithFields returns nil if err is nil.
+func WithFields(err error, fields Fields) error {
+	if err == nil {
+		return nil
+	}
+	if len(fields) == 0 {
+		return err
+	}
+	copied := make(Fields, len(fields))
+	for k, v := range fields {
+		copied[k] = v
+	}
+	return &fieldsError{
+		err:    err,
+		fields: copied,
+	}
+}
+
+// WithField adds the single field to the error.
+// WithField returns nil if err is nil.
+func WithField(err error, key string, value interface{}) error {
+	return WithFields(err, Fields{key: value})
+}
+
+// FieldsOf returns the accumulated fields of the error chain.
+func FieldsOf(err error) Fields {
+	var layers []Fields
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(*fieldsError); ok {
+			layers = append(layers, e.fields)
+		}
+	}
+	if len(layers) == 0 {
+		return nil
+	}
+	fields := make(Fields)
+	for i := len(layers) - 1; i >= 0; i-- {
+		for k, v := range layers[i] {
+			fields[k] = v
+		}
+	}
+	return fields
+}
@@ -0,0 +1,46 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithFields(t *testing.T) {
+	require.NoError(t, WithFields(nil, Fields{"id": 1}))
+	require.NoError(t, WithField(nil, "id", 1))
+
+	base := errors.New("some error")
+	require.Equal(t, base, WithFields(base, nil))
+
+	fields := Fields{"collection": "users"}
+	err := WithFields(NewStorageFindError("user"), fields)
+	fields["collection"] = "changed"
+	err = pkgerrors.Wrap(err, "could not get user")
+	err = WithFields(err, Fields{"id": 42, "operation": "get"})
+	err = WithField(err, "operation", "update")
+
+	require.EqualError(t, err, "could not get user: could not find value: user")
+	require.True(t, errors.Is(err, ErrStorageFind))
+	var e StorageFindError
+	require.True(t, errors.As(err, &e))
+	require.Equal(t, Fields{
+		"collection": "users",
+		"id":         42,
+		"operation":  "update",
+	}, FieldsOf(err))
+	require.Nil(t, FieldsOf(base))
+	require.Nil(t, FieldsOf(nil))
+}
+
+func TestFieldsError_Format(t *testing.T) {
+	cause := pkgerrors.New("some cause")
+	err := WithField(cause, "id", 42)
+	require.Equal(t, "some cause", fmt.Sprintf("%s", err))
+	require.Equal(t, "some cause", fmt.Sprintf("%v", err))
+	require.Equal(t, `"some cause"`, fmt.Sprintf("%q", err))
+	require.Equal(t, fmt.Sprintf("%+v", cause), fmt.Sprintf("%+v", err))
+}
@@ -0,0 +1,297 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/micro/go-micro/v2/client"
+	microerrors "github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/registry"
+	"github.com/micro/go-micro/v2/server"
+	"github.com/pkg/errors"
+)
+
+// microMapping represents the mapping between the typed error and go-micro error.
+// The status is sent in the go-micro error Status field to restore the error type.
+type microMapping struct {
+	sentinel error
+	code     int32
+	status   string
+	build    func(message string) error
+}
+
+// microMappings are ordered from the specific errors to the common ones.
+var microMappings = []microMapping{
+	{
+		sentinel: ErrStorageDuplicate,
+		code:     http.StatusConflict,
+		status:   "StorageDuplicate",
+		build:    func(msg string) error { return NewStorageDuplicateError(msg) },
+	},
+	{
+		sentinel: ErrStorageNotFound,
+		code:     http.StatusNotFound,
+		status:   "StorageNotFound",
+		build:    func(msg string) error { return NewStorageNotFoundError(msg) },
+	},
+	{
+		sentinel: ErrStorageTemporary,
+		code:     http.StatusServiceUnavailable,
+		status:   "StorageTemporary",
+		build:    func(msg string) error { return NewStorageTemporaryError(msg) },
+	},
+	{
+		sentinel: ErrStorageFind,
+		code:     http.StatusNotFound,
+		status:   "StorageFind",
+		build:    func(msg string) error { return NewStorageFindError(msg) },
+	},
+	{
+		sentinel: ErrStorageConvert,
+		code:     http.StatusBadRequest,
+		status:   "StorageConvert",
+		build:    func(msg string) error { return NewStorageConvertError(msg) },
+	},
+	{
+		sentinel: ErrStorageInsert,
+		code:     http.StatusInternalServerError,
+		status:   "StorageInsert",
+		build:    func(msg string) error { return NewStorageInsertError(msg) },
+	},
+	{
+		sentinel: ErrStorageUpdate,
+		code:     http.StatusInternalServerError,
+		status:   "StorageUpdate",
+		build:    func(msg string) error { return NewStorageUpdateError(msg) },
+	},
+	{
+		sentinel: ErrStorageDelete,
+		code:     http.StatusInternalServerError,
+		status:   "StorageDelete",
+		build:    func(msg string) error { return NewStorageDeleteError(msg) },
+	},
+	{
+		sentinel: ErrStorageUnknown,
+		code:     http.StatusInternalServerError,
+		status:   "StorageUnknown",
+		build:    func(msg string) error { return NewStorageUnknownError(msg) },
+	},
+	{
+		sentinel: ErrValidation,
+		code:     http.StatusBadRequest,
+		status:   "Validation",
+		build:    func(msg string) error { return NewValidationError(msg) },
+	},
+	{
+		sentinel: ErrNotFound,
+		code:     http.StatusNotFound,
+		status:   "NotFound",
+		build:    func(msg string) error { return NewNotFoundError(msg) },
+	},
+	{
+		sentinel: ErrAlreadyExists,
+		code:     http.StatusConflict,
+		status:   "AlreadyExists",
+		build:    func(msg string) error { return NewAlreadyExistsError(msg) },
+	},
+	{
+		sentinel: ErrPermissionDenied,
+		code:     http.StatusForbidden,
+		status:   "PermissionDenied",
+		build:    func(msg string) error { return NewPermissionDeniedError(msg) },
+	},
+	{
+		sentinel: ErrUnauthenticated,
+		code:     http.StatusUnauthorized,
+		status:   "Unauthenticated",
+		build:    func(msg string) error { return NewUnauthenticatedError(msg) },
+	},
+	{
+		sentinel: ErrPreconditionFailed,
+		code:     http.StatusPreconditionFailed,
+		status:   "PreconditionFailed",
+		build:    func(msg string) error { return NewPreconditionFailedError(msg) },
+	},
+	{
+		sentinel: ErrRateLimited,
+		code:     http.StatusTooManyRequests,
+		status:   "RateLimited",
+		build:    func(msg string) error { return NewRateLimitedError(msg) },
+	},
+	{
+		sentinel: ErrUnavailable,
+		code:     http.StatusServiceUnavailable,
+		status:   "Unavailable",
+		build:    func(msg string) error { return NewUnavailableError(msg) },
+	},
+}
+
+// validationDetail represents the go-micro error detail of the validation error with violations.
+type validationDetail struct {
+	Detail     string           `json:"detail"`
+	Violations []FieldViolation `json:"violations"`
+}
+
+// ToMicroError converts the storage or domain error to go-micro error with the provided id:
+// StorageFindError becomes NotFound, StorageInsertError with the duplicate cause
+// becomes Conflict, StorageConvertError becomes BadRequest, ValidationError becomes
+// BadRequest, PermissionDeniedError becomes Forbidden and so on.
+// CodeError is sent with its code as go-micro error status.
+// Only the message of the typed error is sent as the detail, the cause errors
+// and the messages of the wrapping errors are not exposed. The violations of
+// the validation error are sent in the detail as JSON.
+// Other errors are returned as is.
+func ToMicroError(id string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var microErr *microerrors.Error
+	if errors.As(err, &microErr) {
+		return err
+	}
+	var codeErr CodeError
+	if errors.As(err, &codeErr) {
+		return &microerrors.Error{
+			Id:     id,
+			Code:   codeErr.def.Status,
+			Detail: codeErr.detail(),
+			Status: string(codeErr.def.Code),
+		}
+	}
+	for i := range microMappings {
+		m := &microMappings[i]
+		if errors.Is(err, m.sentinel) {
+			return &microerrors.Error{
+				Id:     id,
+				Code:   m.code,
+				Detail: microDetail(err),
+				Status: m.status,
+			}
+		}
+	}
+	return err
+}
+
+// microDetail returns the message of the typed error,
+// the validation error with violations is encoded as JSON.
+func microDetail(err error) string {
+	var d detailer
+	if !errors.As(err, &d) {
+		return ""
+	}
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Violations) == 0 {
+		return d.detail()
+	}
+	data, jsonErr := json.Marshal(validationDetail{
+		Detail:     d.detail(),
+		Violations: validationErr.Violations,
+	})
+	if jsonErr != nil {
+		return d.detail()
+	}
+	return string(data)
+}
+
+// remoteError represents the typed error restored from go-micro error.
+// The go-micro error stays reachable by errors.As.
+type remoteError struct {
+	err   error
+	micro *microerrors.Error
+}
+
+// Error returns error as a string value.
+func (e *remoteError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the typed error.
+func (e *remoteError) Unwrap() error {
+	return e.err
+}
+
+// As sets the target to the go-micro error.
+func (e *remoteError) As(target interface{}) bool {
+	if t, ok := target.(**microerrors.Error); ok {
+		*t = e.micro
+		return true
+	}
+	return false
+}
+
+// Format formats the error the same way as the typed error.
+func (e *remoteError) Format(s fmt.State, verb rune) {
+	formatWrapped(s, verb, e.err)
+}
+
+// FromMicroError converts go-micro error created by ToMicroError back to the typed error,
+// so errors.As and errors.Is could be used on the client side.
+// The go-micro error is still matched by errors.As, so its code could be checked as before.
+// Other errors are returned as is.
+func FromMicroError(err error) error {
+	var microErr *microerrors.Error
+	if !errors.As(err, &microErr) {
+		return err
+	}
+	if typed := fromMicroError(microErr); typed != nil {
+		return &remoteError{err: typed, micro: microErr}
+	}
+	return err
+}
+
+// fromMicroError returns the typed error of go-micro error or nil if the error is unknown.
+func fromMicroError(microErr *microerrors.Error) error {
+	for i := range microMappings {
+		m := &microMappings[i]
+		if microErr.Status != m.status || microErr.Code != m.code {
+			continue
+		}
+		var v validationDetail
+		if m.sentinel == ErrValidation && json.Unmarshal([]byte(microErr.Detail), &v) == nil {
+			return ValidationError{
+				DomainError: wrapDomainError(nil, trimKind(v.Detail, m.sentinel), ErrValidation),
+				Violations:  v.Violations,
+			}
+		}
+		return m.build(trimKind(microErr.Detail, m.sentinel))
+	}
+	if def, ok := Lookup(Code(microErr.Status)); ok && def.Status == microErr.Code {
+		msg := microErr.Detail
+		if def.Kind != nil {
+			msg = trimKind(msg, def.Kind)
+		}
+		return NewCodeError(def.Code, msg)
+	}
+	return nil
+}
+
+// trimKind returns the message of the typed error detail without the kind prefix.
+func trimKind(detail string, kind error) string {
+	if detail == kind.Error() {
+		return ""
+	}
+	return strings.TrimPrefix(detail, kind.Error()+": ")
+}
+
+// HandlerWrapper returns go-micro handler wrapper, which converts
+// the typed errors returned by the handlers to go-micro errors.
+func HandlerWrapper() server.HandlerWrapper {
+	return func(fn server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			return ToMicroError(req.Service(), fn(ctx, req, rsp))
+		}
+	}
+}
+
+// CallWrapper returns go-micro client call wrapper, which converts
+// go-micro errors back to the typed errors.
+func CallWrapper() client.CallWrapper {
+	return func(fn client.CallFunc) client.CallFunc {
+		return func(ctx context.Context, node *registry.Node, req client.Request, rsp interface{}, opts client.CallOptions) error {
+			return FromMicroError(fn(ctx, node, req, rsp, opts))
+		}
+	}
+}
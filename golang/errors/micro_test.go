@@ -0,0 +1,230 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/micro/go-micro/v2/client"
+	microerrors "github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/registry"
+	"github.com/micro/go-micro/v2/server"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ToMicroError(t *testing.T) {
+	tt := []struct {
+		name      string
+		err       error
+		expCode   int32
+		expStatus string
+		expAs     interface{}
+		expIs     error
+	}{
+		{
+			name:      "find",
+			err:       NewStorageFindError("user"),
+			expCode:   404,
+			expStatus: "StorageFind",
+			expAs:     &StorageFindError{},
+			expIs:     ErrStorageFind,
+		},
+		{
+			name:      "not found",
+			err:       WrapStorageNotFoundError(errors.New("mongo: no documents in result"), ""),
+			expCode:   404,
+			expStatus: "StorageNotFound",
+			expAs:     &StorageFindError{},
+			expIs:     ErrStorageNotFound,
+		},
+		{
+			name:      "duplicate",
+			err:       WrapStorageDuplicateError(errors.New("E11000 duplicate key error"), "user"),
+			expCode:   409,
+			expStatus: "StorageDuplicate",
+			expAs:     &StorageInsertError{},
+			expIs:     ErrStorageDuplicate,
+		},
+		{
+			name:      "insert with duplicate cause",
+			err:       WrapStorageInsertError(errors.New("E11000 duplicate key error collection: users"), "user"),
+			expCode:   409,
+			expStatus: "StorageDuplicate",
+			expAs:     &StorageInsertError{},
+			expIs:     ErrStorageDuplicate,
+		},
+		{
+			name:      "insert",
+			err:       NewStorageInsertError("user"),
+			expCode:   500,
+			expStatus: "StorageInsert",
+			expAs:     &StorageInsertError{},
+			expIs:     ErrStorageInsert,
+		},
+		{
+			name:      "convert",
+			err:       NewStorageConvertError("user"),
+			expCode:   400,
+			expStatus: "StorageConvert",
+			expAs:     &StorageConvertError{},
+			expIs:     ErrStorageConvert,
+		},
+		{
+			name:      "temporary",
+			err:       NewStorageTemporaryError("user"),
+			expCode:   503,
+			expStatus: "StorageTemporary",
+			expAs:     &StorageUnknownError{},
+			expIs:     ErrStorageTemporary,
+		},
+		{
+			name: "validation",
+			err: NewValidationError("user", FieldViolation{
+				Field:       "email",
+				Description: "must be valid",
+			}),
+			expCode:   400,
+			expStatus: "Validation",
+			expAs:     &ValidationError{},
+			expIs:     ErrValidation,
+		},
+		{
+			name:      "domain not found",
+			err:       NewNotFoundError("user"),
+			expCode:   404,
+			expStatus: "NotFound",
+			expAs:     &NotFoundError{},
+			expIs:     ErrNotFound,
+		},
+		{
+			name:      "permission denied",
+			err:       WrapPermissionDeniedError(errors.New("role is missing"), "user"),
+			expCode:   403,
+			expStatus: "PermissionDenied",
+			expAs:     &PermissionDeniedError{},
+			expIs:     ErrPermissionDenied,
+		},
+		{
+			name:      "rate limited",
+			err:       NewRateLimitedError("user"),
+			expCode:   429,
+			expStatus: "RateLimited",
+			expAs:     &RateLimitedError{},
+			expIs:     ErrRateLimited,
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			err := ToMicroError("user", tc.err)
+			require.Error(t, err)
+			microErr, ok := err.(*microerrors.Error)
+			require.True(t, ok)
+			require.Equal(t, "user", microErr.Id)
+			require.Equal(t, tc.expCode, microErr.Code)
+			require.Equal(t, tc.expStatus, microErr.Status)
+
+			// the client receives the parsed error with the message of the typed error.
+			err = FromMicroError(microerrors.Parse(err.Error()))
+			require.Error(t, err)
+			require.EqualError(t, err, ToProblem(tc.err, "").Detail)
+			require.True(t, errors.As(err, tc.expAs))
+			require.True(t, errors.Is(err, tc.expIs))
+			// the go-micro error is still reachable.
+			require.True(t, errors.As(err, &microErr))
+			require.Equal(t, tc.expCode, microErr.Code)
+		})
+	}
+}
+
+func Test_MicroErrorCause(t *testing.T) {
+	tt := []struct {
+		name          string
+		err           error
+		cause         string
+		expDetail     string
+		expViolations []FieldViolation
+	}{
+		{
+			name:      "storage",
+			err:       WrapStorageDuplicateError(errors.New("E11000 duplicate key error collection: users"), "user"),
+			cause:     "E11000",
+			expDetail: "could not insert value: duplicate value: user",
+		},
+		{
+			name: "validation",
+			err: WrapValidationError(errors.New("regexp: no match for secret@example.com"), "user", FieldViolation{
+				Field:       "email",
+				Description: "must be valid",
+			}),
+			cause:     "secret@example.com",
+			expDetail: "validation failed: user: email: must be valid",
+			expViolations: []FieldViolation{{
+				Field:       "email",
+				Description: "must be valid",
+			}},
+		},
+		{
+			name:      "code",
+			err:       WrapCodeError(errors.New("dial tcp 10.0.0.1:27017: connection refused"), CodeUnavailable, "user"),
+			cause:     "10.0.0.1",
+			expDetail: "unavailable: user",
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			err := ToMicroError("user", fmt.Errorf("could not create user: %w", tc.err))
+			require.Error(t, err)
+			require.NotContains(t, err.Error(), "could not create user")
+			require.NotContains(t, err.Error(), tc.cause)
+
+			err = FromMicroError(microerrors.Parse(err.Error()))
+			require.Error(t, err)
+			require.EqualError(t, err, tc.expDetail)
+			var validationErr ValidationError
+			if errors.As(err, &validationErr) {
+				require.Equal(t, tc.expViolations, validationErr.Violations)
+			}
+		})
+	}
+}
+
+func Test_MicroErrorPassthrough(t *testing.T) {
+	require.NoError(t, ToMicroError("user", nil))
+	require.NoError(t, FromMicroError(nil))
+	err := errors.New("some error")
+	require.Equal(t, err, ToMicroError("user", err))
+	require.Equal(t, err, FromMicroError(err))
+	microErr := microerrors.NotFound("user", "not found")
+	require.Equal(t, microErr, ToMicroError("user", microErr))
+	require.Equal(t, microErr, FromMicroError(microErr))
+}
+
+func Test_MicroWrappers(t *testing.T) {
+	handler := HandlerWrapper()(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		return NewStorageFindError("user")
+	})
+	err := handler(context.Background(), &testRequest{service: "user"}, nil)
+	require.Error(t, err)
+	require.Equal(t, int32(404), err.(*microerrors.Error).Code)
+
+	call := CallWrapper()(func(ctx context.Context, node *registry.Node, req client.Request, rsp interface{}, opts client.CallOptions) error {
+		return err
+	})
+	err = call(context.Background(), nil, nil, nil, client.CallOptions{})
+	require.Error(t, err)
+	var e StorageFindError
+	require.True(t, errors.As(err, &e))
+}
+
+// testRequest implements the request methods used by the handler wrapper.
+type testRequest struct {
+	server.Request
+	service string
+}
+
+func (r *testRequest) Service() string {
+	return r.service
+}
@@ -0,0 +1,100 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MultiError represents the errors collected from several operations.
+// errors.Is and errors.As check every collected error.
+type MultiError struct {
+	Errors []error
+}
+
+// Error returns error as a string value. The message of the single error is returned as is.
+func (m *MultiError) Error() string {
+	switch len(m.Errors) {
+	case 0:
+		return "no errors"
+	case 1:
+		return m.Errors[0].Error()
+	}
+	msgs := make([]string, len(m.Errors))
+	for i := range m.Errors {
+		msgs[i] = m.Errors[i].Error()
+	}
+	return strconv.Itoa(len(m.Errors)) + " errors occurred: " + strings.Join(msgs, "; ")
+}
+
+// Is checks if any of the collected errors matches the target.
+func (m *MultiError) Is(target error) bool {
+	for i := range m.Errors {
+		if errors.Is(m.Errors[i], target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first collected error matching the target.
+func (m *MultiError) As(target interface{}) bool {
+	for i := range m.Errors {
+		if errors.As(m.Errors[i], target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Format formats the error. %+v prints every collected error with its details on a separate line.
+func (m *MultiError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') && len(m.Errors) > 1 {
+			_, _ = fmt.Fprintf(s, "%d errors occurred:", len(m.Errors))
+			for i := range m.Errors {
+				_, _ = fmt.Fprintf(s, "\n\t* %+v", m.Errors[i])
+			}
+			return
+		}
+		if s.Flag('+') && len(m.Errors) == 1 {
+			_, _ = fmt.Fprintf(s, "%+v", m.Errors[0])
+			return
+		}
+		fallthrough
+	case 's':
+		_, _ = io.WriteString(s, m.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", m.Error())
+	}
+}
+
+// ErrorOrNil returns nil if there are no collected errors.
+func (m *MultiError) ErrorOrNil() error {
+	if m == nil || len(m.Errors) == 0 {
+		return nil
+	}
+	return m
+}
+
+// Append appends the errors to err and returns MultiError, nil errors are skipped.
+// The collected errors of MultiError are flattened.
+// Append returns nil if there are no errors.
+func Append(err error, errs ...error) error {
+	var m MultiError
+	for _, e := range append([]error{err}, errs...) {
+		if e == nil {
+			continue
+		}
+		if multi, ok := e.(*MultiError); ok {
+			m.Errors = append(m.Errors, multi.Errors...)
+			continue
+		}
+		m.Errors = append(m.Errors, e)
+	}
+	return m.ErrorOrNil()
+}
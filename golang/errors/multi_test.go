@@ -0,0 +1,68 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Append(t *testing.T) {
+	require.NoError(t, Append(nil))
+	require.NoError(t, Append(nil, nil, nil))
+	require.NoError(t, (&MultiError{}).ErrorOrNil())
+	var m *MultiError
+	require.NoError(t, m.ErrorOrNil())
+
+	err1 := errors.New("error 1")
+	err := Append(nil, err1)
+	require.EqualError(t, err, "error 1")
+
+	err = Append(err, nil, errors.New("error 2"))
+	err = Append(err, Append(errors.New("error 3"), errors.New("error 4")))
+	var multiErr *MultiError
+	require.True(t, errors.As(err, &multiErr))
+	require.Equal(t, 4, len(multiErr.Errors))
+	require.EqualError(t, err, "4 errors occurred: error 1; error 2; error 3; error 4")
+}
+
+func TestMultiError_IsAs(t *testing.T) {
+	cause := errors.New("some cause")
+	err := pkgerrors.Wrap(Append(
+		NewStorageFindError("user"),
+		WrapStorageDuplicateError(cause, "user"),
+		NewValidationError("user", FieldViolation{Field: "email", Description: "must be valid"}),
+	), "could not save user")
+
+	for _, target := range []error{ErrStorageFind, ErrStorageDuplicate, ErrStorageInsert, ErrValidation, cause} {
+		require.True(t, errors.Is(err, target), target.Error())
+	}
+	require.False(t, errors.Is(err, ErrStorageUpdate))
+
+	var insertErr StorageInsertError
+	require.True(t, errors.As(err, &insertErr))
+	require.True(t, errors.Is(insertErr, ErrStorageDuplicate))
+	var validationErr ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Equal(t, "email", validationErr.Violations[0].Field)
+	var unknownErr StorageUnknownError
+	require.False(t, errors.As(err, &unknownErr))
+
+	require.True(t, IsNotFound(err))
+	require.True(t, IsAlreadyExists(err))
+}
+
+func TestMultiError_Format(t *testing.T) {
+	err1 := pkgerrors.New("error 1")
+	err := Append(err1, errors.New("error 2"))
+	require.Equal(t, "2 errors occurred: error 1; error 2", fmt.Sprintf("%s", err))
+	require.Equal(t, "2 errors occurred: error 1; error 2", fmt.Sprintf("%v", err))
+	require.Equal(t, `"2 errors occurred: error 1; error 2"`, fmt.Sprintf("%q", err))
+	require.Equal(t, fmt.Sprintf("2 errors occurred:\n\t* %+v\n\t* error 2", err1), fmt.Sprintf("%+v", err))
+
+	err = Append(err1)
+	require.Equal(t, fmt.Sprintf("%+v", err1), fmt.Sprintf("%+v", err))
+	require.Equal(t, "no errors", (&MultiError{}).Error())
+}
@@ -0,0 +1,136 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// ProblemContentType is the media type of the problem document.
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix is the prefix of the problem type URI, which is followed by the error code.
+const problemTypePrefix = "urn:open-q:error:"
+
+// Problem represents RFC 7807 problem document.
+type Problem struct {
+	// Type is URI of the problem type, e.g. urn:open-q:error:USER_NOT_FOUND.
+	Type string `json:"type"`
+	// Title is the default message of the error code.
+	Title string `json:"title"`
+	// Status is HTTP status of the error code.
+	Status int `json:"status"`
+	// Detail is the message of the typed error without its cause.
+	Detail string `json:"detail,omitempty"`
+	// Instance is the request ID or the request URI.
+	Instance string `json:"instance,omitempty"`
+	// Code is the error code.
+	Code Code `json:"code"`
+	// Violations are the field violations of the validation error.
+	Violations []FieldViolation `json:"violations,omitempty"`
+}
+
+// detailer represents the typed error with the public message.
+type detailer interface {
+	detail() string
+}
+
+func (e StorageError) detail() string {
+	return e.err.Error()
+}
+
+func (e DomainError) detail() string {
+	return e.err.Error()
+}
+
+func (e CodeError) detail() string {
+	return e.err.Error()
+}
+
+// ToProblem converts the error to the problem document with the provided instance.
+// Only the message of the typed error is used as the detail, the cause errors
+// and the messages of the wrapping errors are not exposed.
+// The detail of the unknown error is empty, nil error is the unknown one as well.
+func ToProblem(err error, instance string) Problem {
+	def := DefinitionOf(err)
+	if def.Code == "" {
+		def, _ = Lookup(CodeUnknown)
+	}
+	p := Problem{
+		Type:     problemTypePrefix + string(def.Code),
+		Title:    def.Message,
+		Status:   int(def.Status),
+		Instance: instance,
+		Code:     def.Code,
+	}
+	var d detailer
+	if errors.As(err, &d) {
+		p.Detail = d.detail()
+	}
+	var validationErr ValidationError
+	if errors.As(err, &validationErr) {
+		p.Violations = validationErr.Violations
+	}
+	return p
+}
+
+// FromProblem converts the problem document created by ToProblem back to the typed error,
+// so errors.As and errors.Is could be used on the client side.
+// The unknown code is converted to CodeError with CodeUnknown.
+func FromProblem(p Problem) error {
+	def, ok := Lookup(p.Code)
+	if !ok {
+		return NewCodeError(CodeUnknown, p.Detail)
+	}
+	msg := p.Detail
+	if def.Kind != nil {
+		msg = trimKind(msg, def.Kind)
+	}
+
+	// the common error codes are converted to the storage and domain errors.
+	if def.Kind == ErrValidation && def.Code == CodeValidation {
+		return ValidationError{
+			DomainError: wrapDomainError(nil, msg, ErrValidation),
+			Violations:  p.Violations,
+		}
+	}
+	if isKindCode(def) {
+		for i := range microMappings {
+			if microMappings[i].sentinel == def.Kind {
+				return microMappings[i].build(msg)
+			}
+		}
+	}
+	return NewCodeError(def.Code, msg)
+}
+
+// WriteProblem writes the error as the problem document into the HTTP response.
+func WriteProblem(w http.ResponseWriter, err error, instance string) error {
+	p := ToProblem(err, instance)
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	return errors.Wrap(json.NewEncoder(w).Encode(p), "could not write problem")
+}
+
+// DecodeProblem decodes the problem document. Use FromProblem to get the typed error.
+func DecodeProblem(r io.Reader) (Problem, error) {
+	var p Problem
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return p, errors.Wrap(err, "could not decode problem")
+	}
+	return p, nil
+}
+
+// isKindCode checks if the definition is one of the common error kinds.
+func isKindCode(def Definition) bool {
+	defaultCatalog.mu.RLock()
+	defer defaultCatalog.mu.RUnlock()
+	for _, code := range defaultCatalog.kinds {
+		if code == def.Code {
+			return true
+		}
+	}
+	return false
+}
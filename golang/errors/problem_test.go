@@ -0,0 +1,172 @@
+package errors
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ToProblem(t *testing.T) {
+	cause := errors.New("mongo: no documents in result")
+	tt := []struct {
+		name  string
+		err   error
+		exp   Problem
+		expAs interface{}
+	}{
+		{
+			name: "storage error",
+			err:  pkgerrors.Wrap(WrapStorageNotFoundError(cause, "user"), "could not get user from users collection"),
+			exp: Problem{
+				Type:     "urn:open-q:error:STORAGE_NOT_FOUND",
+				Title:    "value not found",
+				Status:   404,
+				Detail:   "could not find value: value not found: user",
+				Instance: "req-42",
+				Code:     CodeStorageNotFound,
+			},
+			expAs: &StorageFindError{},
+		},
+		{
+			name: "storage error without message",
+			err:  WrapStorageTemporaryError(cause, ""),
+			exp: Problem{
+				Type:     "urn:open-q:error:STORAGE_TEMPORARY",
+				Title:    "temporary storage failure",
+				Status:   503,
+				Detail:   "unknown error: temporary failure",
+				Instance: "req-42",
+				Code:     CodeStorageTemporary,
+			},
+			expAs: &StorageUnknownError{},
+		},
+		{
+			name: "domain error",
+			err:  WrapPermissionDeniedError(cause, "user"),
+			exp: Problem{
+				Type:     "urn:open-q:error:PERMISSION_DENIED",
+				Title:    "permission denied",
+				Status:   403,
+				Detail:   "permission denied: user",
+				Instance: "req-42",
+				Code:     CodePermissionDenied,
+			},
+			expAs: &PermissionDeniedError{},
+		},
+		{
+			name: "validation error",
+			err: NewValidationError("user",
+				FieldViolation{Field: "email", Description: "must be valid"},
+				FieldViolation{Field: "name", Description: "is required"},
+			),
+			exp: Problem{
+				Type:     "urn:open-q:error:VALIDATION_FAILED",
+				Title:    "validation failed",
+				Status:   400,
+				Detail:   "validation failed: user: email: must be valid; name: is required",
+				Instance: "req-42",
+				Code:     CodeValidation,
+				Violations: []FieldViolation{
+					{Field: "email", Description: "must be valid"},
+					{Field: "name", Description: "is required"},
+				},
+			},
+			expAs: &ValidationError{},
+		},
+		{
+			name: "code error",
+			err:  WrapCodeError(cause, testCodeUserNotFound, ""),
+			exp: Problem{
+				Type:     "urn:open-q:error:TEST_USER_NOT_FOUND",
+				Title:    "user not found",
+				Status:   404,
+				Detail:   "not found: user not found",
+				Instance: "req-42",
+				Code:     testCodeUserNotFound,
+			},
+			expAs: &CodeError{},
+		},
+		{
+			name: "unknown error",
+			err:  pkgerrors.Wrap(cause, "could not connect to 10.0.0.1"),
+			exp: Problem{
+				Type:     "urn:open-q:error:UNKNOWN",
+				Title:    "unknown error",
+				Status:   500,
+				Instance: "req-42",
+				Code:     CodeUnknown,
+			},
+			expAs: &CodeError{},
+		},
+		{
+			name: "nil error",
+			exp: Problem{
+				Type:     "urn:open-q:error:UNKNOWN",
+				Title:    "unknown error",
+				Status:   500,
+				Instance: "req-42",
+				Code:     CodeUnknown,
+			},
+			expAs: &CodeError{},
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			p := ToProblem(tc.err, "req-42")
+			require.Equal(t, tc.exp, p)
+
+			err := FromProblem(p)
+			require.Error(t, err)
+			require.True(t, errors.As(err, tc.expAs))
+			require.Equal(t, p.Code, CodeOf(err))
+			if p.Code != CodeUnknown {
+				require.Equal(t, p, ToProblem(err, "req-42"))
+			}
+			require.NotContains(t, err.Error(), cause.Error())
+		})
+	}
+}
+
+func Test_FromProblemUnknownCode(t *testing.T) {
+	err := FromProblem(Problem{Code: "OTHER_SERVICE_ERROR", Status: 418, Detail: "some error"})
+	require.Equal(t, CodeUnknown, CodeOf(err))
+	require.EqualError(t, err, "some error")
+}
+
+func Test_WriteProblem(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := WriteProblem(rec, NewValidationError("", FieldViolation{Field: "email", Description: "must be valid"}), "/users")
+	require.NoError(t, err)
+	require.Equal(t, 400, rec.Code)
+	require.Equal(t, ProblemContentType, rec.Header().Get("Content-Type"))
+	require.JSONEq(t, `{
+		"type": "urn:open-q:error:VALIDATION_FAILED",
+		"title": "validation failed",
+		"status": 400,
+		"detail": "validation failed: email: must be valid",
+		"instance": "/users",
+		"code": "VALIDATION_FAILED",
+		"violations": [{"field": "email", "description": "must be valid"}]
+	}`, rec.Body.String())
+
+	p, err := DecodeProblem(bytes.NewReader(rec.Body.Bytes()))
+	require.NoError(t, err)
+	var validationErr ValidationError
+	require.True(t, errors.As(FromProblem(p), &validationErr))
+	require.Equal(t, "email", validationErr.Violations[0].Field)
+	require.EqualError(t, validationErr, "validation failed: email: must be valid")
+
+	_, err = DecodeProblem(strings.NewReader("not json"))
+	require.Error(t, err)
+
+	rec = httptest.NewRecorder()
+	err = WriteProblem(rec, nil, "/users")
+	require.NoError(t, err)
+	require.Equal(t, 500, rec.Code)
+}
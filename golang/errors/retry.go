@@ -0,0 +1,104 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	microerrors "github.com/micro/go-micro/v2/errors"
+	"github.com/pkg/errors"
+)
+
+// There are the labels of the mongo driver errors. TransientTransactionError,
+// RetryableWriteError and NetworkError mark the failed operation as retryable,
+// UnknownTransactionCommitResult marks the commit, which could be retried.
+// The labels are shared with the storage package, which translates the mongo errors.
+const (
+	MongoLabelTransientTransaction = "TransientTransactionError"
+	MongoLabelRetryableWrite       = "RetryableWriteError"
+	MongoLabelNetwork              = "NetworkError"
+	MongoLabelUnknownCommit        = "UnknownTransactionCommitResult"
+)
+
+// labeledError represents the error with the labels, e.g. mongo.CommandError.
+type labeledError interface {
+	HasErrorLabel(label string) bool
+}
+
+// permanentError represents the error, which must not be retried.
+type permanentError struct {
+	err error
+}
+
+// Error returns error as a string value.
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the provided error.
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Format formats the error the same way as the provided error.
+func (e *permanentError) Format(s fmt.State, verb rune) {
+	formatWrapped(s, verb, e.err)
+}
+
+// Permanent marks the error as permanent, so it is not retried
+// even if it is classified as retryable otherwise.
+// Permanent returns nil if err is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent checks if the error is marked as permanent.
+func IsPermanent(err error) bool {
+	var e *permanentError
+	return errors.As(err, &e)
+}
+
+// IsRetryable checks if the failed operation could be retried:
+//   - the error code definition is retryable, e.g. ErrStorageTemporary, ErrUnavailable or ErrRateLimited;
+//   - mongo driver error has TransientTransactionError, RetryableWriteError or NetworkError label;
+//   - the error is network timeout or temporary network error;
+//   - the error is context.DeadlineExceeded;
+//   - go-micro error is RequestTimeout, TooManyRequests or ServiceUnavailable.
+//
+// The errors marked by Permanent and context.Canceled are not retryable.
+func IsRetryable(err error) bool {
+	if err == nil || IsPermanent(err) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if DefinitionOf(err).Retryable {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var labeled labeledError
+	if errors.As(err, &labeled) {
+		for _, label := range []string{MongoLabelTransientTransaction, MongoLabelRetryableWrite, MongoLabelNetwork} {
+			if labeled.HasErrorLabel(label) {
+				return true
+			}
+		}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && (netErr.Timeout() || netErr.Temporary()) {
+		return true
+	}
+	var microErr *microerrors.Error
+	if errors.As(err, &microErr) {
+		switch microErr.Code {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	microerrors "github.com/micro/go-micro/v2/errors"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// labelError represents the driver error with the labels like mongo.CommandError.
+type labelError struct {
+	labels []string
+}
+
+func (e labelError) Error() string {
+	return "labeled error"
+}
+
+func (e labelError) HasErrorLabel(label string) bool {
+	for _, l := range e.labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// timeoutError represents network timeout error.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return false }
+
+var _ net.Error = timeoutError{}
+
+func Test_IsRetryable(t *testing.T) {
+	tt := []struct {
+		name string
+		err  error
+		exp  bool
+	}{
+		{name: "nil", err: nil, exp: false},
+		{name: "plain error", err: errors.New("some error"), exp: false},
+		{name: "storage temporary", err: NewStorageTemporaryError("user"), exp: true},
+		{name: "storage find", err: NewStorageFindError("user"), exp: false},
+		{name: "unavailable", err: pkgerrors.Wrap(NewUnavailableError("user"), "wrapped"), exp: true},
+		{name: "rate limited", err: NewRateLimitedError("user"), exp: true},
+		{name: "not found", err: NewNotFoundError("user"), exp: false},
+		{name: "transient transaction label", err: labelError{labels: []string{"TransientTransactionError"}}, exp: true},
+		{name: "retryable write label", err: fmt.Errorf("insert: %w", labelError{labels: []string{"RetryableWriteError"}}), exp: true},
+		{name: "network label", err: labelError{labels: []string{"NetworkError"}}, exp: true},
+		{name: "other label", err: labelError{labels: []string{"UnknownTransactionCommitResult"}}, exp: false},
+		{name: "net timeout", err: &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}, exp: true},
+		{name: "deadline exceeded", err: pkgerrors.Wrap(context.DeadlineExceeded, "query"), exp: true},
+		{name: "canceled", err: context.Canceled, exp: false},
+		{name: "micro timeout", err: microerrors.Timeout("user", "request timeout"), exp: true},
+		{name: "micro unavailable", err: microerrors.New("user", "unavailable", 503), exp: true},
+		{name: "micro not found", err: microerrors.NotFound("user", "not found"), exp: false},
+		{name: "retryable code", err: NewCodeError(CodeUnavailable, ""), exp: true},
+		{name: "permanent", err: Permanent(NewStorageTemporaryError("user")), exp: false},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, IsRetryable(tc.err))
+		})
+	}
+}
+
+func Test_Permanent(t *testing.T) {
+	require.NoError(t, Permanent(nil))
+	require.False(t, IsPermanent(nil))
+
+	cause := NewStorageTemporaryError("user")
+	err := pkgerrors.Wrap(Permanent(cause), "wrapped")
+	require.True(t, IsPermanent(err))
+	require.True(t, errors.Is(err, ErrStorageTemporary))
+	require.EqualError(t, err, "wrapped: unknown error: temporary failure: user")
+	require.Equal(t, fmt.Sprintf("%+v", cause), fmt.Sprintf("%+v", Permanent(cause)))
+	require.False(t, IsPermanent(cause))
+}
@@ -0,0 +1,67 @@
+package errors
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// maxStackDepth is the max number of the captured stack frames.
+const maxStackDepth = 32
+
+// stackCapture is 1 if the stack traces are captured on the error creation.
+var stackCapture int32 = 1
+
+// SetStackCapture turns on or off the stack trace capture on the creation of the typed errors.
+// The capture is on by default.
+func SetStackCapture(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&stackCapture, v)
+}
+
+// StackCaptureEnabled reports whether the stack traces are captured.
+func StackCaptureEnabled() bool {
+	return atomic.LoadInt32(&stackCapture) == 1
+}
+
+// stack represents the program counters of the stack frames.
+// The frames are resolved only when the stack trace is printed.
+type stack []uintptr
+
+// callers captures the stack of the caller of the exported constructor.
+// The skip is the number of the frames between the constructor and callers.
+func callers(skip int) *stack {
+	if !StackCaptureEnabled() {
+		return nil
+	}
+	var pcs [maxStackDepth]uintptr
+	// skip runtime.Callers, callers and the constructor itself.
+	n := runtime.Callers(skip+3, pcs[:])
+	st := stack(pcs[:n])
+	return &st
+}
+
+// StackTrace returns the stack trace compatible with github.com/pkg/errors.
+func (s *stack) StackTrace() errors.StackTrace {
+	if s == nil {
+		return nil
+	}
+	st := make(errors.StackTrace, len(*s))
+	for i, pc := range *s {
+		st[i] = errors.Frame(pc)
+	}
+	return st
+}
+
+// format prints the stack trace for %+v the same way as github.com/pkg/errors.
+func (s *stack) format(st fmt.State) {
+	if s == nil {
+		return
+	}
+	s.StackTrace().Format(st, 'v')
+}
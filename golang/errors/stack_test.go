@@ -0,0 +1,88 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// stackTracer represents the error with the stack trace of github.com/pkg/errors.
+type stackTracer interface {
+	StackTrace() pkgerrors.StackTrace
+}
+
+func Test_StackCapture(t *testing.T) {
+	tt := []struct {
+		name string
+		new  func() error
+	}{
+		{
+			name: "storage error",
+			new:  func() error { return NewStorageFindError("user") },
+		},
+		{
+			name: "wrapped storage error",
+			new:  func() error { return WrapStorageDuplicateError(errors.New("some cause"), "") },
+		},
+		{
+			name: "domain error",
+			new:  func() error { return NewValidationError("user") },
+		},
+		{
+			name: "code error",
+			new:  func() error { return NewCodeError(CodeNotFound, "user") },
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			err := pkgerrors.WithMessage(tc.new(), "could not get user")
+			var st stackTracer
+			require.True(t, errors.As(err, &st))
+			trace := st.StackTrace()
+			require.NotEmpty(t, trace)
+			// the first frame is the caller of the constructor.
+			require.Equal(t, "Test_StackCapture.func"+fmt.Sprint(i+1), fmt.Sprintf("%n", trace[0]))
+			require.Equal(t, "stack_test.go", fmt.Sprintf("%s", trace[0]))
+
+			details := fmt.Sprintf("%+v", err)
+			require.Contains(t, details, "errors.Test_StackCapture.func")
+			require.Contains(t, details, "stack_test.go:")
+			require.NotContains(t, fmt.Sprintf("%v", err), "stack_test.go")
+		})
+	}
+}
+
+func Test_SetStackCapture(t *testing.T) {
+	require.True(t, StackCaptureEnabled())
+	SetStackCapture(false)
+	defer SetStackCapture(true)
+	require.False(t, StackCaptureEnabled())
+
+	err := NewStorageFindError("user")
+	require.Nil(t, err.StackTrace())
+	require.Equal(t, "could not find value: user", fmt.Sprintf("%+v", err))
+
+	err = WrapStorageFindError(pkgerrors.New("some cause"), "user")
+	require.Nil(t, err.StackTrace())
+	details := fmt.Sprintf("%+v", err)
+	require.True(t, strings.HasPrefix(details, "could not find value: user: some cause\n"))
+	// the stack of the cause is printed.
+	require.Contains(t, details, "Test_SetStackCapture")
+}
+
+func Benchmark_NewStorageFindError(b *testing.B) {
+	for _, enabled := range []bool{true, false} {
+		b.Run(fmt.Sprintf("capture %t", enabled), func(b *testing.B) {
+			SetStackCapture(enabled)
+			defer SetStackCapture(true)
+			for i := 0; i < b.N; i++ {
+				_ = NewStorageFindError("user")
+			}
+		})
+	}
+}
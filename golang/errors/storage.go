@@ -2,6 +2,7 @@ package errors
 
 import (
 	"fmt"
+	"regexp"
 
 	"github.com/pkg/errors"
 )
@@ -16,9 +17,22 @@ var (
 	ErrStorageUnknown = errors.New("unknown error")
 )
 
+// There are specific storage errors, which match their common errors as well.
+var (
+	ErrStorageDuplicate = fmt.Errorf("%w: duplicate value", ErrStorageInsert)
+	ErrStorageNotFound  = fmt.Errorf("%w: value not found", ErrStorageFind)
+	ErrStorageTemporary = fmt.Errorf("%w: temporary failure", ErrStorageUnknown)
+)
+
+// duplicateKeyPattern matches the mongo error codes of the unique index violation.
+var duplicateKeyPattern = regexp.MustCompile(`\bE(11000|11001|12582)\b`)
+
 // StorageError represents common storage error structure.
+// The error matches its sentinel error and the optional cause error.
 type StorageError struct {
-	err error
+	err   error
+	cause error
+	stack *stack
 }
 
 type (
@@ -50,12 +64,46 @@ type (
 
 // Error returns error as a string value.
 func (e StorageError) Error() string {
-	return e.err.Error()
+	return chainError(e.err, e.cause)
 }
 
-// Unwrap returns the low level of the provided error.
+// Unwrap returns the cause error if any, otherwise the low level of the provided error.
 func (e StorageError) Unwrap() error {
-	return errors.Unwrap(e.err)
+	return chainUnwrap(e.err, e.cause)
+}
+
+// Is checks if the sentinel error of the provided error matches the target.
+// The cause error is checked by errors.Is through Unwrap.
+func (e StorageError) Is(target error) bool {
+	return errors.Is(e.err, target)
+}
+
+// StackTrace returns the stack trace of the error creation compatible with github.com/pkg/errors.
+// The stack trace is nil if the capture is turned off by SetStackCapture.
+func (e StorageError) StackTrace() errors.StackTrace {
+	return e.stack.StackTrace()
+}
+
+// Format formats the error. %+v prints the whole chain including the cause details and the stack trace.
+func (e StorageError) Format(s fmt.State, verb rune) {
+	chainFormat(s, verb, e.err, e.cause, e.stack)
+}
+
+// IsStorageError checks if the error is one of the storage errors.
+func IsStorageError(err error) bool {
+	for _, target := range []error{
+		ErrStorageConvert,
+		ErrStorageInsert,
+		ErrStorageDelete,
+		ErrStorageUpdate,
+		ErrStorageFind,
+		ErrStorageUnknown,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
 }
 
 // NewStorageConvertError creates new StorageConvertError instance.
@@ -63,33 +111,125 @@ func NewStorageConvertError(message string) StorageConvertError {
 	return StorageConvertError{newStorageError(message, ErrStorageConvert)}
 }
 
+// WrapStorageConvertError creates new StorageConvertError instance with the cause error.
+// The message is optional.
+func WrapStorageConvertError(err error, message string) StorageConvertError {
+	return StorageConvertError{wrapStorageError(err, message, ErrStorageConvert)}
+}
+
 // NewStorageInsertError creates new StorageInsertError instance.
 func NewStorageInsertError(message string) StorageInsertError {
 	return StorageInsertError{newStorageError(message, ErrStorageInsert)}
 }
 
+// WrapStorageInsertError creates new StorageInsertError instance with the cause error.
+// The error matches ErrStorageDuplicate if the cause is the mongo duplicate key error.
+// The message is optional.
+func WrapStorageInsertError(err error, message string) StorageInsertError {
+	if isDuplicateCause(err) {
+		return StorageInsertError{wrapStorageError(err, message, ErrStorageDuplicate)}
+	}
+	return StorageInsertError{wrapStorageError(err, message, ErrStorageInsert)}
+}
+
 // NewStorageDeleteError creates new StorageDeleteError instance.
 func NewStorageDeleteError(message string) StorageDeleteError {
 	return StorageDeleteError{newStorageError(message, ErrStorageDelete)}
 }
 
+// WrapStorageDeleteError creates new StorageDeleteError instance with the cause error.
+// The message is optional.
+func WrapStorageDeleteError(err error, message string) StorageDeleteError {
+	return StorageDeleteError{wrapStorageError(err, message, ErrStorageDelete)}
+}
+
 // NewStorageUpdateError creates new StorageUpdateError instance.
 func NewStorageUpdateError(message string) StorageUpdateError {
 	return StorageUpdateError{newStorageError(message, ErrStorageUpdate)}
 }
 
+// WrapStorageUpdateError creates new StorageUpdateError instance with the cause error.
+// The message is optional.
+func WrapStorageUpdateError(err error, message string) StorageUpdateError {
+	return StorageUpdateError{wrapStorageError(err, message, ErrStorageUpdate)}
+}
+
 // NewStorageFindError creates new StorageFindError instance.
 func NewStorageFindError(message string) StorageFindError {
 	return StorageFindError{newStorageError(message, ErrStorageFind)}
 }
 
+// WrapStorageFindError creates new StorageFindError instance with the cause error.
+// The message is optional.
+func WrapStorageFindError(err error, message string) StorageFindError {
+	return StorageFindError{wrapStorageError(err, message, ErrStorageFind)}
+}
+
 // NewStorageUnknownError creates new StorageUnknownError instance.
 func NewStorageUnknownError(message string) StorageUnknownError {
 	return StorageUnknownError{newStorageError(message, ErrStorageUnknown)}
 }
 
+// WrapStorageUnknownError creates new StorageUnknownError instance with the cause error.
+// The message is optional.
+func WrapStorageUnknownError(err error, message string) StorageUnknownError {
+	return StorageUnknownError{wrapStorageError(err, message, ErrStorageUnknown)}
+}
+
+// NewStorageDuplicateError creates new StorageInsertError instance,
+// which represents a unique constraint violation.
+func NewStorageDuplicateError(message string) StorageInsertError {
+	return StorageInsertError{newStorageError(message, ErrStorageDuplicate)}
+}
+
+// WrapStorageDuplicateError creates new StorageInsertError instance with the cause error.
+// The message is optional.
+func WrapStorageDuplicateError(err error, message string) StorageInsertError {
+	return StorageInsertError{wrapStorageError(err, message, ErrStorageDuplicate)}
+}
+
+// NewStorageNotFoundError creates new StorageFindError instance,
+// which represents a missing value.
+func NewStorageNotFoundError(message string) StorageFindError {
+	return StorageFindError{newStorageError(message, ErrStorageNotFound)}
+}
+
+// WrapStorageNotFoundError creates new StorageFindError instance with the cause error.
+// The message is optional.
+func WrapStorageNotFoundError(err error, message string) StorageFindError {
+	return StorageFindError{wrapStorageError(err, message, ErrStorageNotFound)}
+}
+
+// NewStorageTemporaryError creates new StorageUnknownError instance,
+// which represents a temporary failure, e.g. timeout or network error.
+// The operation could be retried.
+func NewStorageTemporaryError(message string) StorageUnknownError {
+	return StorageUnknownError{newStorageError(message, ErrStorageTemporary)}
+}
+
+// WrapStorageTemporaryError creates new StorageUnknownError instance with the cause error.
+// The message is optional.
+func WrapStorageTemporaryError(err error, message string) StorageUnknownError {
+	return StorageUnknownError{wrapStorageError(err, message, ErrStorageTemporary)}
+}
+
 func newStorageError(msg string, err error) *StorageError {
 	return &StorageError{
-		err: fmt.Errorf("%w: %s", err, msg),
+		err:   withMessage(err, msg),
+		stack: callers(1),
 	}
 }
+
+func wrapStorageError(cause error, msg string, err error) *StorageError {
+	return &StorageError{
+		err:   withMessage(err, msg),
+		cause: cause,
+		stack: callers(1),
+	}
+}
+
+// isDuplicateCause checks if the cause error reports the unique index violation
+// by the mongo error codes in its message, e.g. "E11000 duplicate key error".
+func isDuplicateCause(err error) bool {
+	return err != nil && (errors.Is(err, ErrStorageDuplicate) || duplicateKeyPattern.MatchString(err.Error()))
+}
@@ -3,8 +3,10 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
+	pkgerrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -86,3 +88,152 @@ func Test_NewStorageUnknownError(t *testing.T) {
 	require.True(t, errors.As(err, &e))
 	require.EqualError(t, err, fmt.Sprintf("%v: some error", ErrStorageUnknown))
 }
+
+func Test_NewStorageDuplicateError(t *testing.T) {
+	err := NewStorageDuplicateError("some error")
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrStorageDuplicate))
+	require.True(t, errors.Is(err, ErrStorageInsert))
+	var e StorageInsertError
+	require.True(t, errors.As(err, &e))
+	require.EqualError(t, err, fmt.Sprintf("%v: duplicate value: some error", ErrStorageInsert))
+}
+
+func Test_NewStorageNotFoundError(t *testing.T) {
+	err := NewStorageNotFoundError("some error")
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrStorageNotFound))
+	require.True(t, errors.Is(err, ErrStorageFind))
+	var e StorageFindError
+	require.True(t, errors.As(err, &e))
+	require.EqualError(t, err, fmt.Sprintf("%v: value not found: some error", ErrStorageFind))
+}
+
+func Test_NewStorageTemporaryError(t *testing.T) {
+	err := NewStorageTemporaryError("some error")
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrStorageTemporary))
+	require.True(t, errors.Is(err, ErrStorageUnknown))
+	var e StorageUnknownError
+	require.True(t, errors.As(err, &e))
+	require.EqualError(t, err, fmt.Sprintf("%v: temporary failure: some error", ErrStorageUnknown))
+}
+
+func Test_WrapStorageError(t *testing.T) {
+	cause := &causeError{msg: "driver error"}
+	tt := []struct {
+		name     string
+		err      error
+		sentinel error
+		expAs    interface{}
+		expError string
+	}{
+		{
+			name:     "convert",
+			err:      WrapStorageConvertError(cause, "user"),
+			sentinel: ErrStorageConvert,
+			expAs:    &StorageConvertError{},
+			expError: "could not convert value: user: driver error",
+		},
+		{
+			name:     "insert",
+			err:      WrapStorageInsertError(cause, ""),
+			sentinel: ErrStorageInsert,
+			expAs:    &StorageInsertError{},
+			expError: "could not insert value: driver error",
+		},
+		{
+			name:     "delete",
+			err:      WrapStorageDeleteError(cause, "user"),
+			sentinel: ErrStorageDelete,
+			expAs:    &StorageDeleteError{},
+			expError: "could not delete value: user: driver error",
+		},
+		{
+			name:     "update",
+			err:      WrapStorageUpdateError(cause, "user"),
+			sentinel: ErrStorageUpdate,
+			expAs:    &StorageUpdateError{},
+			expError: "could not update value: user: driver error",
+		},
+		{
+			name:     "find",
+			err:      WrapStorageFindError(cause, "user"),
+			sentinel: ErrStorageFind,
+			expAs:    &StorageFindError{},
+			expError: "could not find value: user: driver error",
+		},
+		{
+			name:     "unknown",
+			err:      WrapStorageUnknownError(cause, "user"),
+			sentinel: ErrStorageUnknown,
+			expAs:    &StorageUnknownError{},
+			expError: "unknown error: user: driver error",
+		},
+		{
+			name:     "duplicate",
+			err:      WrapStorageDuplicateError(cause, ""),
+			sentinel: ErrStorageDuplicate,
+			expAs:    &StorageInsertError{},
+			expError: "could not insert value: duplicate value: driver error",
+		},
+		{
+			name:     "not found",
+			err:      WrapStorageNotFoundError(cause, ""),
+			sentinel: ErrStorageNotFound,
+			expAs:    &StorageFindError{},
+			expError: "could not find value: value not found: driver error",
+		},
+		{
+			name:     "temporary",
+			err:      WrapStorageTemporaryError(cause, ""),
+			sentinel: ErrStorageTemporary,
+			expAs:    &StorageUnknownError{},
+			expError: "unknown error: temporary failure: driver error",
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			require.Error(t, tc.err)
+			require.EqualError(t, tc.err, tc.expError)
+			require.True(t, errors.Is(tc.err, tc.sentinel))
+			require.True(t, errors.Is(tc.err, cause))
+			require.True(t, IsStorageError(tc.err))
+			require.True(t, errors.As(tc.err, tc.expAs))
+			var c *causeError
+			require.True(t, errors.As(tc.err, &c))
+			require.Equal(t, cause, c)
+			require.Equal(t, cause, errors.Unwrap(tc.err))
+		})
+	}
+}
+
+func TestStorageError_Format(t *testing.T) {
+	cause := pkgerrors.WithStack(&causeError{msg: "driver error"})
+	err := WrapStorageFindError(cause, "user")
+	require.Equal(t, "could not find value: user: driver error", fmt.Sprintf("%v", err))
+	require.Equal(t, "could not find value: user: driver error", fmt.Sprintf("%s", err))
+	require.Equal(t, `"could not find value: user: driver error"`, fmt.Sprintf("%q", err))
+	details := fmt.Sprintf("%+v", err)
+	require.True(t, strings.HasPrefix(details, "could not find value: user: driver error\n"))
+	require.Contains(t, details, "TestStorageError_Format")
+	// without the cause.
+	details = fmt.Sprintf("%+v", NewStorageFindError("user"))
+	require.True(t, strings.HasPrefix(details, "could not find value: user\n"))
+	require.Contains(t, details, "TestStorageError_Format")
+}
+
+func Test_IsStorageError(t *testing.T) {
+	require.True(t, IsStorageError(NewStorageUnknownError("some error")))
+	require.True(t, IsStorageError(fmt.Errorf("wrapped: %w", NewStorageFindError("some error"))))
+	require.False(t, IsStorageError(errors.New("some error")))
+}
+
+type causeError struct {
+	msg string
+}
+
+func (e *causeError) Error() string {
+	return e.msg
+}
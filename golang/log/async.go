@@ -0,0 +1,220 @@
+package log
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultAsyncBufferSize    = 1024
+	defaultAsyncFlushInterval = time.Second
+)
+
+// ErrWriterClosed is returned on writing into the closed writer.
+var ErrWriterClosed = errors.New("writer is closed")
+
+// OverflowPolicy represents the behaviour of the AsyncWriter when its buffer is full.
+type OverflowPolicy int
+
+// There are available overflow policies.
+const (
+	// OverflowBlock blocks the writing until there is a space in the buffer.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest buffered line to free the space.
+	OverflowDropOldest
+	// OverflowDrop drops the line being written.
+	OverflowDrop
+)
+
+// AsyncConfig represents AsyncWriter configuration.
+type AsyncConfig struct {
+	// BufferSize is the max number of the buffered lines, 1024 by default.
+	BufferSize int
+	// FlushInterval is the period of the underlying writer flushing, 1s by default.
+	FlushInterval time.Duration
+	// Overflow is the policy to apply when the buffer is full.
+	Overflow OverflowPolicy
+}
+
+// AsyncWriter represents writer which writes the data into the underlying writer
+// in the background goroutine. Close or Flush must be called before the exit,
+// otherwise the buffered data will be lost.
+type AsyncWriter struct {
+	w        io.Writer
+	bw       *bufio.Writer
+	overflow OverflowPolicy
+	queue    chan []byte
+	flushes  chan chan error
+	done     chan struct{}
+	stopped  chan struct{}
+	mu       sync.RWMutex
+	closed   bool
+	dropped  uint64
+	err      error
+}
+
+// NewAsyncWriter creates new AsyncWriter instance on top of the provided writer.
+func NewAsyncWriter(w io.Writer, cfg AsyncConfig) *AsyncWriter {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultAsyncBufferSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultAsyncFlushInterval
+	}
+
+	aw := AsyncWriter{
+		w:        w,
+		bw:       bufio.NewWriter(w),
+		overflow: cfg.Overflow,
+		queue:    make(chan []byte, cfg.BufferSize),
+		flushes:  make(chan chan error),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go aw.run(cfg.FlushInterval)
+
+	return &aw
+}
+
+// Write puts a copy of the data into the buffer.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+	// the formatters may skip the entry by returning no data.
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	line := make([]byte, len(p))
+	copy(line, p)
+
+	switch w.overflow {
+	case OverflowDrop:
+		select {
+		case w.queue <- line:
+		default:
+			atomic.AddUint64(&w.dropped, 1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case w.queue <- line:
+				return len(p), nil
+			default:
+			}
+			select {
+			case <-w.queue:
+				atomic.AddUint64(&w.dropped, 1)
+			default:
+			}
+		}
+	default:
+		w.queue <- line
+	}
+
+	return len(p), nil
+}
+
+// Dropped returns the number of the lines dropped due to the buffer overflow.
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Flush writes all the buffered data into the underlying writer.
+func (w *AsyncWriter) Flush() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	res := make(chan error)
+	w.flushes <- res
+	return <-res
+}
+
+// Close flushes all the buffered data and stops the writer.
+// The underlying writer is closed as well if it implements io.Closer.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrWriterClosed
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.done)
+	<-w.stopped
+
+	if c, ok := w.w.(io.Closer); ok {
+		if err := c.Close(); err != nil && w.err == nil {
+			w.err = err
+		}
+	}
+	return w.err
+}
+
+func (w *AsyncWriter) run(interval time.Duration) {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case line := <-w.queue:
+			w.write(line)
+		case <-ticker.C:
+			w.flush()
+		case res := <-w.flushes:
+			w.drain()
+			res <- w.flush()
+		case <-w.done:
+			w.drain()
+			w.flush()
+			return
+		}
+	}
+}
+
+// drain writes all the queued lines.
+func (w *AsyncWriter) drain() {
+	for {
+		select {
+		case line := <-w.queue:
+			w.write(line)
+		default:
+			return
+		}
+	}
+}
+
+func (w *AsyncWriter) write(line []byte) {
+	// flush the buffer before it's overflowed to never split a line
+	// between two writes into the underlying writer.
+	if len(line) > w.bw.Available() && w.bw.Buffered() > 0 {
+		w.flush()
+	}
+	if _, err := w.bw.Write(line); err != nil {
+		w.err = errors.Wrap(err, "could not write log line")
+	}
+}
+
+func (w *AsyncWriter) flush() error {
+	if err := w.bw.Flush(); err != nil {
+		w.err = errors.Wrap(err, "could not flush log data")
+		return w.err
+	}
+	return nil
+}
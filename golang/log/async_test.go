@@ -0,0 +1,142 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsyncWriter_Flush(t *testing.T) {
+	var buf syncBuffer
+	w := NewAsyncWriter(&buf, AsyncConfig{FlushInterval: time.Hour})
+	for _, line := range []string{"line 1\n", "line 2\n"} {
+		n, err := w.Write([]byte(line))
+		require.NoError(t, err)
+		require.Equal(t, len(line), n)
+	}
+	err := w.Flush()
+	require.NoError(t, err)
+	require.Equal(t, "line 1\nline 2\n", buf.String())
+	err = w.Close()
+	require.NoError(t, err)
+}
+
+func TestAsyncWriter_PeriodicFlush(t *testing.T) {
+	var buf syncBuffer
+	w := NewAsyncWriter(&buf, AsyncConfig{FlushInterval: 5 * time.Millisecond})
+	defer w.Close()
+	_, err := w.Write([]byte("line\n"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return buf.String() == "line\n"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestAsyncWriter_Overflow(t *testing.T) {
+	tt := []struct {
+		name       string
+		overflow   OverflowPolicy
+		expLines   []string
+		expDropped uint64
+	}{
+		{
+			name:       "drop",
+			overflow:   OverflowDrop,
+			expLines:   []string{"1", "2"},
+			expDropped: 2,
+		},
+		{
+			name:       "drop oldest",
+			overflow:   OverflowDropOldest,
+			expLines:   []string{"3", "4"},
+			expDropped: 2,
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			// the writer goroutine is not started, so nothing is taken from the buffer.
+			w := AsyncWriter{
+				overflow: tc.overflow,
+				queue:    make(chan []byte, 2),
+			}
+			for _, line := range []string{"1", "2", "3", "4"} {
+				n, err := w.Write([]byte(line))
+				require.NoError(t, err)
+				require.Equal(t, len(line), n)
+			}
+			require.Equal(t, tc.expDropped, w.Dropped())
+			close(w.queue)
+			lines := make([]string, 0, len(tc.expLines))
+			for line := range w.queue {
+				lines = append(lines, string(line))
+			}
+			require.Equal(t, tc.expLines, lines)
+		})
+	}
+}
+
+func TestAsyncWriter_Close(t *testing.T) {
+	var buf syncBuffer
+	w := NewAsyncWriter(&buf, AsyncConfig{})
+	_, err := w.Write([]byte("line\n"))
+	require.NoError(t, err)
+	err = w.Close()
+	require.NoError(t, err)
+	require.Equal(t, "line\n", buf.String())
+
+	_, err = w.Write([]byte("line\n"))
+	require.Equal(t, ErrWriterClosed, err)
+	require.Equal(t, ErrWriterClosed, w.Flush())
+	require.Equal(t, ErrWriterClosed, w.Close())
+}
+
+func TestLogger_Close(t *testing.T) {
+	l, err := NewFileLogger("./testlog/async", "test.json", os.ModePerm, WithAsync(AsyncConfig{FlushInterval: time.Hour}))
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll("./testlog")
+		require.NoError(t, err)
+	}()
+	for i := 0; i < 100; i++ {
+		l.Infof("hello %d", i)
+	}
+	err = l.Flush()
+	require.NoError(t, err)
+	l.Info("last")
+	err = l.Close()
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile("./testlog/async/test.json")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Equal(t, 101, len(lines))
+	var logMap map[string]interface{}
+	err = json.Unmarshal([]byte(lines[100]), &logMap)
+	require.NoError(t, err)
+	require.Equal(t, "last", logMap["msg"])
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
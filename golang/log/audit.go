@@ -0,0 +1,441 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultAuditCheckpointEvery = 100
+
+	// There are audit record types.
+	auditEventType      = "event"
+	auditCheckpointType = "checkpoint"
+)
+
+// There are audit log verification errors.
+var (
+	ErrAuditMalformed  = errors.New("malformed audit record")
+	ErrAuditHash       = errors.New("audit record hash mismatch")
+	ErrAuditChain      = errors.New("audit chain is broken")
+	ErrAuditSequence   = errors.New("audit sequence is broken")
+	ErrAuditCheckpoint = errors.New("audit checkpoint mismatch")
+	ErrAuditTruncated  = errors.New("audit log is truncated")
+)
+
+// AuditEvent represents audited event, e.g. user status change.
+type AuditEvent struct {
+	Action  string
+	Actor   string
+	Subject string
+	Data    map[string]interface{}
+}
+
+// AuditRecord represents a line of the audit log.
+// Each record contains the hash of the previous one,
+// so any edited, reordered or deleted record breaks the chain.
+// The hashes are HMAC-SHA256 with the audit key, so the chain
+// could not be rebuilt after the editing without the key.
+type AuditRecord struct {
+	Seq      uint64          `json:"seq"`
+	Time     time.Time       `json:"time"`
+	Type     string          `json:"type"`
+	Action   string          `json:"action,omitempty"`
+	Actor    string          `json:"actor,omitempty"`
+	Subject  string          `json:"subject,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	PrevHash string          `json:"prev_hash"`
+	Hash     string          `json:"hash,omitempty"`
+}
+
+// auditCheckpoint represents the data of the checkpoint record.
+type auditCheckpoint struct {
+	Events uint64 `json:"events"`
+	Hash   string `json:"hash"`
+}
+
+// AuditHead represents the head of the audit chain: the sequence number and the hash
+// of the checkpoint record. The heads are written to the anchor outside of the audit log,
+// so cutting the tail of the log including the checkpoints is detected by VerifyAudit.
+type AuditHead struct {
+	Seq  uint64    `json:"seq"`
+	Hash string    `json:"hash"`
+	Time time.Time `json:"time"`
+}
+
+// AuditConfig represents AuditLogger configuration.
+type AuditConfig struct {
+	// Key is the HMAC key of the record hashes, it's required.
+	// It must be kept outside of the audit log host, e.g. in the secret storage.
+	Key []byte
+	// CheckpointEvery is the number of the events between checkpoints, 100 by default.
+	CheckpointEvery int
+	// Anchor receives the chain head as a JSON line on every checkpoint,
+	// e.g. a file on another volume or a write-once storage. It's synced if it's a file.
+	// The key doesn't protect from cutting the tail of the log, the anchored head does.
+	Anchor io.Writer
+	// Head is the last anchored head, the existing audit log must contain it.
+	Head *AuditHead
+	// OnTornTail is called with the incomplete last line left by a crash during the writing.
+	// The line is removed from the audit log on opening.
+	OnTornTail func(tail []byte)
+}
+
+// AuditLogger represents append-only audit logger, separate from the diagnostic log.
+type AuditLogger struct {
+	mu     sync.Mutex
+	f      *os.File
+	key    []byte
+	anchor io.Writer
+	every  int
+	seq    uint64
+	events uint64
+	prev   string
+	now    func() time.Time
+}
+
+// auditVerifier verifies the audit chain record by record.
+type auditVerifier struct {
+	key    []byte
+	seq    uint64
+	events uint64
+	prev   string
+	line   int
+	// head is the expected anchored head.
+	head *AuditHead
+	// headFound is set when the record of the expected head is verified.
+	headFound bool
+}
+
+// NewAuditLogger opens the audit log file, verifies its chain and continues it.
+// The incomplete last line left by a crash during the writing is removed,
+// any other violation of the chain is returned as the verification error.
+func NewAuditLogger(path string, perm os.FileMode, cfg AuditConfig) (*AuditLogger, error) {
+	if len(cfg.Key) == 0 {
+		return nil, errors.New("audit key is required")
+	}
+	if cfg.CheckpointEvery <= 0 {
+		cfg.CheckpointEvery = defaultAuditCheckpointEvery
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, perm)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open audit log file %s", path)
+	}
+
+	v, err := openAuditChain(f, cfg)
+	if err != nil {
+		_ = f.Close()
+		return nil, errors.Wrapf(err, "could not verify audit log file %s", path)
+	}
+
+	return &AuditLogger{
+		f:      f,
+		key:    cfg.Key,
+		anchor: cfg.Anchor,
+		every:  cfg.CheckpointEvery,
+		seq:    v.seq,
+		events: v.events,
+		prev:   v.prev,
+		now:    time.Now,
+	}, nil
+}
+
+// openAuditChain verifies the chain of the opened audit log file and repairs its torn tail.
+func openAuditChain(f *os.File, cfg AuditConfig) (*auditVerifier, error) {
+	v := auditVerifier{key: cfg.Key, head: cfg.Head}
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		data, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(data) == 0 {
+				break
+			}
+			// the last line without the new line and incomplete JSON is written partially.
+			if !json.Valid(data) {
+				if err := f.Truncate(offset); err != nil {
+					return nil, errors.Wrap(err, "could not remove torn audit record")
+				}
+				if cfg.OnTornTail != nil {
+					cfg.OnTornTail(data)
+				}
+				break
+			}
+			// only the new line is lost.
+			if err := v.verify(data); err != nil {
+				return nil, err
+			}
+			if _, err := f.Write([]byte{'\n'}); err != nil {
+				return nil, errors.Wrap(err, "could not complete audit record")
+			}
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read audit log")
+		}
+		if err := v.verify(data); err != nil {
+			return nil, err
+		}
+		offset += int64(len(data))
+	}
+	if err := v.verifyHead(); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Log appends the event record to the audit log.
+// A checkpoint is appended after every configured number of events.
+func (a *AuditLogger) Log(event AuditEvent) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rec := AuditRecord{
+		Type:    auditEventType,
+		Action:  event.Action,
+		Actor:   event.Actor,
+		Subject: event.Subject,
+	}
+	if len(event.Data) != 0 {
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			return errors.Wrap(err, "could not marshal audit data")
+		}
+		rec.Data = data
+	}
+	if err := a.append(&rec); err != nil {
+		return err
+	}
+	a.events++
+
+	if a.events%uint64(a.every) == 0 {
+		return a.checkpoint()
+	}
+	return nil
+}
+
+// Checkpoint appends the checkpoint record and syncs the audit log to the disk.
+func (a *AuditLogger) Checkpoint() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.checkpoint()
+}
+
+// Close closes the audit log file.
+func (a *AuditLogger) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.f.Close()
+}
+
+func (a *AuditLogger) checkpoint() error {
+	data, err := json.Marshal(auditCheckpoint{
+		Events: a.events,
+		Hash:   a.prev,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not marshal audit checkpoint")
+	}
+	rec := AuditRecord{Type: auditCheckpointType, Data: data}
+	if err := a.append(&rec); err != nil {
+		return err
+	}
+	if err := a.f.Sync(); err != nil {
+		return errors.Wrap(err, "could not sync audit log")
+	}
+	if a.anchor == nil {
+		return nil
+	}
+
+	head, err := json.Marshal(AuditHead{
+		Seq:  rec.Seq,
+		Hash: rec.Hash,
+		Time: rec.Time,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not marshal audit head")
+	}
+	if _, err := a.anchor.Write(append(head, '\n')); err != nil {
+		return errors.Wrap(err, "could not write audit head")
+	}
+	if s, ok := a.anchor.(interface{ Sync() error }); ok {
+		if err := s.Sync(); err != nil {
+			return errors.Wrap(err, "could not sync audit anchor")
+		}
+	}
+	return nil
+}
+
+func (a *AuditLogger) append(rec *AuditRecord) error {
+	rec.Seq = a.seq + 1
+	rec.Time = a.now().UTC()
+	rec.PrevHash = a.prev
+
+	hash, err := auditHash(a.key, rec)
+	if err != nil {
+		return err
+	}
+	rec.Hash = hash
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal audit record")
+	}
+	if _, err := a.f.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, "could not write audit record")
+	}
+
+	a.seq = rec.Seq
+	a.prev = rec.Hash
+	return nil
+}
+
+// auditHash returns the HMAC of the record without its own hash.
+func auditHash(key []byte, rec *AuditRecord) (string, error) {
+	r := *rec
+	r.Hash = ""
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", errors.Wrap(err, "could not marshal audit record")
+	}
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyAudit verifies the audit log chain with the audit key. If the head is provided, the log
+// must contain its checkpoint record, so the cut tail of the log is detected as ErrAuditTruncated.
+// Returns the number of the verified records and the error describing the first violation.
+func VerifyAudit(r io.Reader, key []byte, head *AuditHead) (uint64, error) {
+	if len(key) == 0 {
+		return 0, errors.New("audit key is required")
+	}
+	v := auditVerifier{key: key, head: head}
+	scanner := newAuditScanner(r)
+	for scanner.Scan() {
+		if err := v.verify(scanner.Bytes()); err != nil {
+			return v.seq, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return v.seq, errors.Wrap(err, "could not read audit log")
+	}
+	return v.seq, v.verifyHead()
+}
+
+// VerifyAuditFile verifies the audit log file chain.
+func VerifyAuditFile(path string, key []byte, head *AuditHead) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, errors.Wrapf(err, "could not open audit log file %s", path)
+	}
+	defer f.Close()
+	return VerifyAudit(f, key, head)
+}
+
+// ReadAuditHead returns the last head written to the anchor or nil if there are no heads.
+// The incomplete last line left by a crash during the writing is skipped.
+func ReadAuditHead(r io.Reader) (*AuditHead, error) {
+	var (
+		head *AuditHead
+		torn bool
+	)
+	scanner := newAuditScanner(r)
+	for scanner.Scan() {
+		if torn {
+			return nil, errors.Wrap(ErrAuditMalformed, "malformed audit head")
+		}
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var h AuditHead
+		if err := json.Unmarshal(scanner.Bytes(), &h); err != nil {
+			// only the last line is allowed to be torn.
+			torn = true
+			continue
+		}
+		head = &h
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "could not read audit anchor")
+	}
+	return head, nil
+}
+
+// verify verifies the next line of the audit log.
+func (v *auditVerifier) verify(data []byte) error {
+	v.line++
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	var rec AuditRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return errors.Wrapf(ErrAuditMalformed, "line %d: %v", v.line, err)
+	}
+
+	hash, err := auditHash(v.key, &rec)
+	if err != nil {
+		return errors.Wrapf(err, "line %d", v.line)
+	}
+	if !hmac.Equal([]byte(hash), []byte(rec.Hash)) {
+		return errors.Wrapf(ErrAuditHash, "line %d", v.line)
+	}
+	if rec.Seq != v.seq+1 {
+		return errors.Wrapf(ErrAuditSequence, "line %d: expected %d, got %d", v.line, v.seq+1, rec.Seq)
+	}
+	if rec.PrevHash != v.prev {
+		return errors.Wrapf(ErrAuditChain, "line %d", v.line)
+	}
+
+	switch rec.Type {
+	case auditEventType:
+		v.events++
+	case auditCheckpointType:
+		var cp auditCheckpoint
+		if err := json.Unmarshal(rec.Data, &cp); err != nil {
+			return errors.Wrapf(ErrAuditMalformed, "line %d: %v", v.line, err)
+		}
+		if cp.Events != v.events || cp.Hash != v.prev {
+			return errors.Wrapf(ErrAuditCheckpoint, "line %d", v.line)
+		}
+	default:
+		return errors.Wrapf(ErrAuditMalformed, "line %d: unknown record type %q", v.line, rec.Type)
+	}
+
+	if v.head != nil && rec.Seq == v.head.Seq {
+		if rec.Type != auditCheckpointType || rec.Hash != v.head.Hash {
+			return errors.Wrapf(ErrAuditCheckpoint, "line %d: anchored head mismatch", v.line)
+		}
+		v.headFound = true
+	}
+
+	v.seq = rec.Seq
+	v.prev = rec.Hash
+	return nil
+}
+
+// verifyHead checks that the verified chain contains the expected head.
+func (v *auditVerifier) verifyHead() error {
+	if v.head == nil || v.headFound {
+		return nil
+	}
+	return errors.Wrapf(ErrAuditTruncated, "expected head %d, got %d", v.head.Seq, v.seq)
+}
+
+func newAuditScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<20)
+	return scanner
+}
@@ -0,0 +1,290 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var testAuditKey = []byte("audit-secret")
+
+func Test_NewAuditLogger(t *testing.T) {
+	t.Run("missing key error", func(t *testing.T) {
+		_, err := NewAuditLogger(path.Join(os.TempDir(), "audit.log"), os.ModePerm, AuditConfig{})
+		require.Error(t, err)
+		require.EqualError(t, err, "audit key is required")
+	})
+	t.Run("open file error", func(t *testing.T) {
+		_, err := NewAuditLogger("./testlog/missing/audit.log", os.ModePerm, AuditConfig{Key: testAuditKey})
+		require.Error(t, err)
+		require.EqualError(t, err, "could not open audit log file ./testlog/missing/audit.log: open ./testlog/missing/audit.log: no such file or directory")
+	})
+	t.Run("malformed file error", func(t *testing.T) {
+		fPath := path.Join(os.TempDir(), "audit.log")
+		err := ioutil.WriteFile(fPath, []byte("invalid\n"), 0666)
+		require.NoError(t, err)
+		defer os.Remove(fPath)
+		_, err = NewAuditLogger(fPath, os.ModePerm, AuditConfig{Key: testAuditKey})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrAuditMalformed))
+	})
+	t.Run("continue the chain", func(t *testing.T) {
+		fPath := path.Join(os.TempDir(), "audit.log")
+		defer os.Remove(fPath)
+		for i := 0; i < 3; i++ {
+			a, err := NewAuditLogger(fPath, os.ModePerm, AuditConfig{Key: testAuditKey, CheckpointEvery: 2})
+			require.NoError(t, err)
+			err = a.Log(AuditEvent{Action: "user.status.change", Subject: "user-1"})
+			require.NoError(t, err)
+			err = a.Close()
+			require.NoError(t, err)
+		}
+		n, err := VerifyAuditFile(fPath, testAuditKey, nil)
+		require.NoError(t, err)
+		// 3 events and a checkpoint after the second one.
+		require.Equal(t, uint64(4), n)
+	})
+	t.Run("tampered file error", func(t *testing.T) {
+		fPath := path.Join(os.TempDir(), "audit.log")
+		defer os.Remove(fPath)
+		a, err := NewAuditLogger(fPath, os.ModePerm, AuditConfig{Key: testAuditKey})
+		require.NoError(t, err)
+		err = a.Log(AuditEvent{Action: "user.status.change", Subject: "user-1"})
+		require.NoError(t, err)
+		err = a.Close()
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadFile(fPath)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(fPath, bytes.Replace(data, []byte("user-1"), []byte("user-2"), 1), 0666)
+		require.NoError(t, err)
+		_, err = NewAuditLogger(fPath, os.ModePerm, AuditConfig{Key: testAuditKey})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrAuditHash), err.Error())
+	})
+	t.Run("truncated file error", func(t *testing.T) {
+		fPath := path.Join(os.TempDir(), "audit.log")
+		defer os.Remove(fPath)
+		var anchor bytes.Buffer
+		a, err := NewAuditLogger(fPath, os.ModePerm, AuditConfig{Key: testAuditKey, Anchor: &anchor})
+		require.NoError(t, err)
+		err = a.Log(AuditEvent{Action: "user.status.change", Subject: "user-1"})
+		require.NoError(t, err)
+		err = a.Checkpoint()
+		require.NoError(t, err)
+		err = a.Close()
+		require.NoError(t, err)
+		head, err := ReadAuditHead(&anchor)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), head.Seq)
+
+		// cut the checkpoint.
+		data, err := ioutil.ReadFile(fPath)
+		require.NoError(t, err)
+		lines := strings.SplitAfter(string(data), "\n")
+		err = ioutil.WriteFile(fPath, []byte(lines[0]), 0666)
+		require.NoError(t, err)
+		_, err = NewAuditLogger(fPath, os.ModePerm, AuditConfig{Key: testAuditKey, Head: head})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrAuditTruncated), err.Error())
+	})
+	t.Run("torn tail", func(t *testing.T) {
+		fPath := path.Join(os.TempDir(), "audit.log")
+		defer os.Remove(fPath)
+		a, err := NewAuditLogger(fPath, os.ModePerm, AuditConfig{Key: testAuditKey})
+		require.NoError(t, err)
+		err = a.Log(AuditEvent{Action: "user.status.change", Subject: "user-1"})
+		require.NoError(t, err)
+		err = a.Close()
+		require.NoError(t, err)
+
+		f, err := os.OpenFile(fPath, os.O_APPEND|os.O_WRONLY, 0666)
+		require.NoError(t, err)
+		_, err = f.WriteString(`{"seq":2,"time":"2020-`)
+		require.NoError(t, err)
+		err = f.Close()
+		require.NoError(t, err)
+
+		var tail []byte
+		a, err = NewAuditLogger(fPath, os.ModePerm, AuditConfig{
+			Key:        testAuditKey,
+			OnTornTail: func(t []byte) { tail = t },
+		})
+		require.NoError(t, err)
+		require.Equal(t, `{"seq":2,"time":"2020-`, string(tail))
+		err = a.Log(AuditEvent{Action: "user.status.change", Subject: "user-1"})
+		require.NoError(t, err)
+		err = a.Close()
+		require.NoError(t, err)
+		n, err := VerifyAuditFile(fPath, testAuditKey, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), n)
+	})
+	t.Run("lost new line", func(t *testing.T) {
+		fPath := path.Join(os.TempDir(), "audit.log")
+		defer os.Remove(fPath)
+		a, err := NewAuditLogger(fPath, os.ModePerm, AuditConfig{Key: testAuditKey})
+		require.NoError(t, err)
+		err = a.Log(AuditEvent{Action: "user.status.change", Subject: "user-1"})
+		require.NoError(t, err)
+		err = a.Close()
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadFile(fPath)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(fPath, bytes.TrimRight(data, "\n"), 0666)
+		require.NoError(t, err)
+		a, err = NewAuditLogger(fPath, os.ModePerm, AuditConfig{Key: testAuditKey})
+		require.NoError(t, err)
+		err = a.Log(AuditEvent{Action: "user.status.change", Subject: "user-1"})
+		require.NoError(t, err)
+		err = a.Close()
+		require.NoError(t, err)
+		n, err := VerifyAuditFile(fPath, testAuditKey, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), n)
+	})
+}
+
+func Test_ReadAuditHead(t *testing.T) {
+	head, err := ReadAuditHead(bytes.NewBufferString(""))
+	require.NoError(t, err)
+	require.Nil(t, head)
+
+	head, err = ReadAuditHead(bytes.NewBufferString("{\"seq\":3,\"hash\":\"a\"}\n{\"seq\":6,\"hash\":\"b\"}\n{\"seq\":9,"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), head.Seq)
+	require.Equal(t, "b", head.Hash)
+
+	_, err = ReadAuditHead(bytes.NewBufferString("{\n{\"seq\":6,\"hash\":\"b\"}\n"))
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrAuditMalformed))
+}
+
+func Test_VerifyAudit(t *testing.T) {
+	tt := []struct {
+		name   string
+		tamper func(lines []string) []string
+		expErr error
+	}{
+		{
+			name:   "all ok",
+			tamper: func(lines []string) []string { return lines },
+		},
+		{
+			name: "edited record",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"blocked"`, `"active"`, 1)
+				return lines
+			},
+			expErr: ErrAuditHash,
+		},
+		{
+			name: "edited record with rebuilt chain",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"blocked"`, `"active"`, 1)
+				return rehashAudit(lines, []byte("guessed-key"))
+			},
+			expErr: ErrAuditHash,
+		},
+		{
+			name: "reordered records",
+			tamper: func(lines []string) []string {
+				lines[0], lines[1] = lines[1], lines[0]
+				return lines
+			},
+			expErr: ErrAuditSequence,
+		},
+		{
+			name: "deleted record",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			expErr: ErrAuditSequence,
+		},
+		{
+			name: "malformed record",
+			tamper: func(lines []string) []string {
+				lines[2] = "{"
+				return lines
+			},
+			expErr: ErrAuditMalformed,
+		},
+		{
+			name: "cut tail with checkpoints",
+			tamper: func(lines []string) []string {
+				return lines[:3]
+			},
+			expErr: ErrAuditTruncated,
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			fPath := path.Join(os.TempDir(), "audit.log")
+			defer os.Remove(fPath)
+			var anchor bytes.Buffer
+			a, err := NewAuditLogger(fPath, os.ModePerm, AuditConfig{Key: testAuditKey, CheckpointEvery: 2, Anchor: &anchor})
+			require.NoError(t, err)
+			for _, status := range []string{"active", "blocked", "deleted"} {
+				err = a.Log(AuditEvent{
+					Action:  "user.status.change",
+					Actor:   "admin",
+					Subject: "user-1",
+					Data:    map[string]interface{}{"status": status, "attempt": 1},
+				})
+				require.NoError(t, err)
+			}
+			err = a.Checkpoint()
+			require.NoError(t, err)
+			err = a.Close()
+			require.NoError(t, err)
+
+			head, err := ReadAuditHead(&anchor)
+			require.NoError(t, err)
+			require.Equal(t, uint64(5), head.Seq)
+
+			data, err := ioutil.ReadFile(fPath)
+			require.NoError(t, err)
+			lines := tc.tamper(strings.Split(strings.TrimSpace(string(data)), "\n"))
+			n, err := VerifyAudit(bytes.NewBufferString(strings.Join(lines, "\n")), testAuditKey, head)
+			if tc.expErr != nil {
+				require.Error(t, err)
+				require.True(t, errors.Is(err, tc.expErr), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, uint64(5), n)
+		})
+	}
+}
+
+// rehashAudit rebuilds the chain of the audit log lines with the key.
+func rehashAudit(lines []string, key []byte) []string {
+	var prev string
+	for i := range lines {
+		var rec AuditRecord
+		if err := json.Unmarshal([]byte(lines[i]), &rec); err != nil {
+			continue
+		}
+		if rec.Type == auditCheckpointType {
+			var cp auditCheckpoint
+			_ = json.Unmarshal(rec.Data, &cp)
+			cp.Hash = prev
+			rec.Data, _ = json.Marshal(cp)
+		}
+		rec.PrevHash = prev
+		rec.Hash, _ = auditHash(key, &rec)
+		data, _ := json.Marshal(rec)
+		lines[i] = string(data)
+		prev = rec.Hash
+	}
+	return lines
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"strings"
+
+	errs "github.com/open-Q/common/golang/errors"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// There are the fields of the error entries.
+const (
+	FieldErrorCode  = "error_code"
+	FieldErrorChain = "error_chain"
+)
+
+// ErrorFields returns the structured fields of the error: the error message,
+// the error code, the cause chain and the key/value fields added by errors.WithFields.
+// The cause chain contains the distinct messages of the wrapped causes from the outer one,
+// the sentinel errors of the typed errors are skipped.
+func ErrorFields(err error) logrus.Fields {
+	if err == nil {
+		return logrus.Fields{}
+	}
+	fields := mergeFields(nil, errs.FieldsOf(err))
+	fields[logrus.ErrorKey] = err
+	fields[FieldErrorCode] = string(errs.CodeOf(err))
+	if chain := errorChain(err); len(chain) > 1 {
+		fields[FieldErrorChain] = chain
+	}
+	return fields
+}
+
+// WithErrorFields returns a log entry with the structured fields of the error, see ErrorFields.
+func (l *Logger) WithErrorFields(err error) *logrus.Entry {
+	return l.WithFields(ErrorFields(err))
+}
+
+func errorChain(err error) []string {
+	var chain []string
+	for ; err != nil; err = errors.Unwrap(err) {
+		msg := err.Error()
+		if len(chain) == 0 {
+			chain = append(chain, msg)
+			continue
+		}
+		// the cause message ends the message of the wrapping error.
+		if last := chain[len(chain)-1]; last != msg && strings.HasSuffix(last, msg) {
+			chain = append(chain, msg)
+		}
+	}
+	return chain
+}
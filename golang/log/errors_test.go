@@ -0,0 +1,90 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	errs "github.com/open-Q/common/golang/errors"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ErrorFields(t *testing.T) {
+	cause := errors.New("mongo: no documents in result")
+	storageErr := errs.WithFields(errs.WrapStorageNotFoundError(cause, ""), errs.Fields{
+		"collection": "users",
+		"operation":  "find",
+	})
+	tt := []struct {
+		name string
+		err  error
+		exp  logrus.Fields
+	}{
+		{
+			name: "nil",
+			exp:  logrus.Fields{},
+		},
+		{
+			name: "plain error",
+			err:  errors.New("some error"),
+			exp: logrus.Fields{
+				"error":        errors.New("some error"),
+				FieldErrorCode: "UNKNOWN",
+			},
+		},
+		{
+			name: "storage error with fields",
+			err:  errs.WithField(errors.Wrap(storageErr, "could not get user"), "id", 42),
+			exp: logrus.Fields{
+				"error":        errs.WithField(errors.Wrap(storageErr, "could not get user"), "id", 42),
+				FieldErrorCode: "STORAGE_NOT_FOUND",
+				FieldErrorChain: []string{
+					"could not get user: could not find value: value not found: mongo: no documents in result",
+					"could not find value: value not found: mongo: no documents in result",
+					"mongo: no documents in result",
+				},
+				"collection": "users",
+				"operation":  "find",
+				"id":         42,
+			},
+		},
+		{
+			name: "error field is not overridden",
+			err:  errs.WithField(errs.NewNotFoundError("user"), "error", "custom"),
+			exp: logrus.Fields{
+				"error":        errs.WithField(errs.NewNotFoundError("user"), "error", "custom"),
+				FieldErrorCode: "NOT_FOUND",
+			},
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			fields := ErrorFields(tc.err)
+			require.Equal(t, len(tc.exp), len(fields))
+			for k, v := range tc.exp {
+				if err, ok := v.(error); ok {
+					require.EqualError(t, fields[k].(error), err.Error())
+					continue
+				}
+				require.Equal(t, v, fields[k], k)
+			}
+		})
+	}
+}
+
+func TestLogger_WithErrorFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := newTestLogger(buf)
+	err := errs.WithField(errs.NewStorageTemporaryError("users"), "collection", "users")
+	l.WithErrorFields(err).Error("could not get user")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "unknown error: temporary failure: users", entry["error"])
+	require.Equal(t, "STORAGE_TEMPORARY", entry[FieldErrorCode])
+	require.Equal(t, "users", entry["collection"])
+	require.NotContains(t, entry, FieldErrorChain)
+}
@@ -0,0 +1,106 @@
+package log
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoLogFile is returned on reopening of the logger without a log file.
+var ErrNoLogFile = errors.New("logger has no log file")
+
+// FileWriter represents log file writer which is able to reopen the file by its path,
+// e.g. after the file has been moved by logrotate.
+type FileWriter struct {
+	mu   sync.Mutex
+	path string
+	perm os.FileMode
+	f    *os.File
+}
+
+// OpenFileWriter opens the log file for appending.
+func OpenFileWriter(path string, perm os.FileMode) (*FileWriter, error) {
+	f, err := openLogFile(path, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &FileWriter{
+		path: path,
+		perm: perm,
+		f:    f,
+	}, nil
+}
+
+// Write writes the data into the current log file.
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f.Write(p)
+}
+
+// Reopen opens the file by its path and closes the previous one.
+// The writes are blocked during the swap, so nothing is lost or interleaved.
+// The previous file is kept if the new one could not be opened.
+func (w *FileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	f, err := openLogFile(w.path, w.perm)
+	if err != nil {
+		return errors.Wrapf(err, "could not reopen log file %s", w.path)
+	}
+	prev := w.f
+	w.f = f
+
+	return prev.Close()
+}
+
+// Close closes the current log file.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f.Close()
+}
+
+func openLogFile(path string, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, perm)
+}
+
+// Reopen reopens the log file of the logger.
+func (l *Logger) Reopen() error {
+	if l.file == nil {
+		return ErrNoLogFile
+	}
+	// write the buffered lines into the previous file first.
+	if err := l.Flush(); err != nil {
+		return err
+	}
+	return l.file.Reopen()
+}
+
+// ReopenOnSignal reopens the log file of the logger on the signal receiving,
+// SIGHUP sent by logrotate by default. Blocks until the context is done.
+func (l *Logger) ReopenOnSignal(ctx context.Context, sig ...os.Signal) {
+	if len(sig) == 0 {
+		// signal.Notify without signals subscribes to all of them including SIGINT and SIGTERM.
+		sig = []os.Signal{syscall.SIGHUP}
+	}
+	c := make(chan os.Signal, 1)
+	notifySignal(c, sig...)
+	defer signal.Stop(c)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c:
+			if err := l.Reopen(); err != nil {
+				l.WithError(err).Error("could not reopen log file")
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileWriter_Reopen(t *testing.T) {
+	t.Run("reopen error", func(t *testing.T) {
+		err := os.MkdirAll("./testlog/reopen", os.ModePerm)
+		require.NoError(t, err)
+		defer func() {
+			err := os.RemoveAll("./testlog")
+			require.NoError(t, err)
+		}()
+		w, err := OpenFileWriter("./testlog/reopen/test.json", os.ModePerm)
+		require.NoError(t, err)
+		err = os.RemoveAll("./testlog/reopen")
+		require.NoError(t, err)
+		err = w.Reopen()
+		require.Error(t, err)
+		require.EqualError(t, err, "could not reopen log file ./testlog/reopen/test.json: open ./testlog/reopen/test.json: no such file or directory")
+		// the previous file is still in use.
+		_, err = w.Write([]byte("line\n"))
+		require.NoError(t, err)
+		err = w.Close()
+		require.NoError(t, err)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		err := os.MkdirAll("./testlog/reopen", os.ModePerm)
+		require.NoError(t, err)
+		defer func() {
+			err := os.RemoveAll("./testlog")
+			require.NoError(t, err)
+		}()
+		w, err := OpenFileWriter("./testlog/reopen/test.json", os.ModePerm)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("line 1\n"))
+		require.NoError(t, err)
+		err = os.Rename("./testlog/reopen/test.json", "./testlog/reopen/test.json.1")
+		require.NoError(t, err)
+		_, err = w.Write([]byte("line 2\n"))
+		require.NoError(t, err)
+		err = w.Reopen()
+		require.NoError(t, err)
+		_, err = w.Write([]byte("line 3\n"))
+		require.NoError(t, err)
+		err = w.Close()
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadFile("./testlog/reopen/test.json.1")
+		require.NoError(t, err)
+		require.Equal(t, "line 1\nline 2\n", string(data))
+		data, err = ioutil.ReadFile("./testlog/reopen/test.json")
+		require.NoError(t, err)
+		require.Equal(t, "line 3\n", string(data))
+	})
+}
+
+func TestLogger_Reopen(t *testing.T) {
+	t.Run("no log file error", func(t *testing.T) {
+		l := New(logrus.New())
+		require.Equal(t, ErrNoLogFile, l.Reopen())
+	})
+	t.Run("concurrent writes", func(t *testing.T) {
+		l, err := NewFileLogger("./testlog/reopen", "test.json", os.ModePerm, WithAsync(AsyncConfig{}))
+		require.NoError(t, err)
+		defer func() {
+			err := os.RemoveAll("./testlog")
+			require.NoError(t, err)
+		}()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(child *Logger) {
+				defer wg.Done()
+				for i := 0; i < 500; i++ {
+					child.WithField("payload", strings.Repeat("x", 100)).Infof("line %d", i)
+				}
+			}(l.Named("worker"))
+		}
+		for i := 1; i <= 3; i++ {
+			time.Sleep(time.Millisecond)
+			err = os.Rename("./testlog/reopen/test.json", "./testlog/reopen/test.json."+string(rune('0'+i)))
+			require.NoError(t, err)
+			err = l.Reopen()
+			require.NoError(t, err)
+		}
+		wg.Wait()
+		err = l.Close()
+		require.NoError(t, err)
+
+		var total int
+		for _, name := range []string{"test.json", "test.json.1", "test.json.2", "test.json.3"} {
+			data, err := ioutil.ReadFile("./testlog/reopen/" + name)
+			require.NoError(t, err)
+			for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+				if line == "" {
+					continue
+				}
+				var logMap map[string]interface{}
+				err = json.Unmarshal([]byte(line), &logMap)
+				require.NoError(t, err)
+				total++
+			}
+		}
+		require.Equal(t, 2000, total)
+	})
+}
+
+func TestLogger_ReopenOnSignal(t *testing.T) {
+	l, err := NewFileLogger("./testlog/reopen", "test.json", os.ModePerm)
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll("./testlog")
+		require.NoError(t, err)
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	ready := notifyReady(t)
+	done := make(chan struct{})
+	go func() {
+		// SIGHUP by default.
+		l.ReopenOnSignal(ctx)
+		close(done)
+	}()
+	<-ready
+
+	err = os.Rename("./testlog/reopen/test.json", "./testlog/reopen/test.json.1")
+	require.NoError(t, err)
+	err = syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		_, err := os.Stat("./testlog/reopen/test.json")
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	l.Info("after rotation")
+	err = l.Close()
+	require.NoError(t, err)
+	data, err := ioutil.ReadFile("./testlog/reopen/test.json")
+	require.NoError(t, err)
+	require.Contains(t, string(data), "after rotation")
+}
@@ -0,0 +1,185 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// There are supported log formats.
+const (
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+	FormatECS    = "ecs"
+)
+
+const ecsVersion = "1.6.0"
+
+// NewFormatter creates new formatter of the provided format.
+// Service name and version are added to the logfmt and ECS entries.
+func NewFormatter(format, serviceName, serviceVersion string) (logrus.Formatter, error) {
+	switch strings.ToLower(format) {
+	case "", FormatJSON:
+		return &logrus.JSONFormatter{}, nil
+	case FormatLogfmt:
+		return &LogfmtFormatter{
+			ServiceName:    serviceName,
+			ServiceVersion: serviceVersion,
+		}, nil
+	case FormatECS:
+		return &ECSFormatter{
+			ServiceName:    serviceName,
+			ServiceVersion: serviceVersion,
+		}, nil
+	default:
+		return nil, errors.Errorf("unknown log format %s", format)
+	}
+}
+
+// LogfmtFormatter formats entries as logfmt lines.
+type LogfmtFormatter struct {
+	ServiceName    string
+	ServiceVersion string
+	// TimestampFormat is time.RFC3339Nano by default.
+	TimestampFormat string
+}
+
+// Format formats the entry as a logfmt line.
+func (f *LogfmtFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	timestampFormat := f.TimestampFormat
+	if timestampFormat == "" {
+		timestampFormat = time.RFC3339Nano
+	}
+
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+
+	writeLogfmtPair(b, "time", entry.Time.Format(timestampFormat))
+	writeLogfmtPair(b, "level", entry.Level.String())
+	writeLogfmtPair(b, "msg", entry.Message)
+	if f.ServiceName != "" {
+		writeLogfmtPair(b, "service", f.ServiceName)
+	}
+	if f.ServiceVersion != "" {
+		writeLogfmtPair(b, "version", f.ServiceVersion)
+	}
+	if entry.HasCaller() {
+		writeLogfmtPair(b, "func", entry.Caller.Function)
+		writeLogfmtPair(b, "file", fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line))
+	}
+	for _, key := range sortedKeys(entry.Data) {
+		if (key == FieldService && f.ServiceName != "") || (key == FieldVersion && f.ServiceVersion != "") {
+			// the service fields are already written.
+			continue
+		}
+		writeLogfmtPair(b, key, fieldString(entry.Data[key]))
+	}
+	b.WriteByte('\n')
+
+	return b.Bytes(), nil
+}
+
+func writeLogfmtPair(b *bytes.Buffer, key, value string) {
+	if b.Len() != 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	if value == "" || strings.ContainsAny(value, " =\"\t\r\n\\") {
+		b.WriteString(strconv.Quote(value))
+		return
+	}
+	b.WriteString(value)
+}
+
+// ECSFormatter formats entries as Elastic Common Schema JSON documents.
+type ECSFormatter struct {
+	ServiceName    string
+	ServiceVersion string
+}
+
+// Format formats the entry as an ECS JSON line.
+func (f *ECSFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	doc := make(map[string]interface{}, len(entry.Data)+7)
+	for k, v := range entry.Data {
+		switch k {
+		case logrus.ErrorKey:
+			k = "error.message"
+		case FieldErrorCode:
+			k = "error.code"
+		case FieldService:
+			k = "service.name"
+		case FieldVersion:
+			k = "service.version"
+		case "@timestamp", "message", "log.level", "ecs.version":
+			// keep the schema fields untouched.
+			k = "fields." + k
+		}
+		doc[k] = fieldValue(v)
+	}
+
+	doc["@timestamp"] = entry.Time.UTC().Format(time.RFC3339Nano)
+	doc["log.level"] = entry.Level.String()
+	doc["message"] = entry.Message
+	doc["ecs.version"] = ecsVersion
+	if f.ServiceName != "" {
+		doc["service.name"] = f.ServiceName
+	}
+	if f.ServiceVersion != "" {
+		doc["service.version"] = f.ServiceVersion
+	}
+	if entry.HasCaller() {
+		doc["log.origin.function"] = entry.Caller.Function
+		doc["log.origin.file.name"] = entry.Caller.File
+		doc["log.origin.file.line"] = entry.Caller.Line
+	}
+
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	if err := json.NewEncoder(b).Encode(doc); err != nil {
+		return nil, errors.Wrap(err, "could not marshal fields to JSON")
+	}
+	return b.Bytes(), nil
+}
+
+func sortedKeys(fields logrus.Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fieldValue returns JSON friendly field value.
+func fieldValue(v interface{}) interface{} {
+	if err, ok := v.(error); ok {
+		return err.Error()
+	}
+	return v
+}
+
+// fieldString returns string representation of the field value.
+func fieldString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
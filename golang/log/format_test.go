@@ -0,0 +1,137 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewFormatter(t *testing.T) {
+	tt := []struct {
+		name   string
+		format string
+		exp    logrus.Formatter
+		expErr string
+	}{
+		{
+			name:   "default",
+			format: "",
+			exp:    &logrus.JSONFormatter{},
+		},
+		{
+			name:   "logfmt",
+			format: "logfmt",
+			exp:    &LogfmtFormatter{ServiceName: "user", ServiceVersion: "0.0.1"},
+		},
+		{
+			name:   "ecs",
+			format: "ECS",
+			exp:    &ECSFormatter{ServiceName: "user", ServiceVersion: "0.0.1"},
+		},
+		{
+			name:   "unknown format error",
+			format: "xml",
+			expErr: "unknown log format xml",
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := NewFormatter(tc.format, "user", "0.0.1")
+			if tc.expErr != "" {
+				require.Error(t, err)
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, f)
+		})
+	}
+}
+
+func TestLogfmtFormatter_Format(t *testing.T) {
+	f := LogfmtFormatter{ServiceName: "user", ServiceVersion: "0.0.1"}
+	entry := logrus.Entry{
+		Time:    time.Date(2020, 11, 2, 10, 0, 0, 0, time.UTC),
+		Level:   logrus.WarnLevel,
+		Message: "could not find user",
+		Data: logrus.Fields{
+			"id":    123,
+			"error": errors.New("not found"),
+			"empty": "",
+			"query": `name="john"`,
+			// written once as the service field.
+			FieldService: "user",
+		},
+	}
+	data, err := f.Format(&entry)
+	require.NoError(t, err)
+	require.Equal(t, `time=2020-11-02T10:00:00Z level=warning msg="could not find user" service=user version=0.0.1 empty="" error="not found" id=123 query="name=\"john\""`+"\n", string(data))
+}
+
+func TestECSFormatter_Format(t *testing.T) {
+	f := ECSFormatter{ServiceName: "user", ServiceVersion: "0.0.1"}
+	entry := logrus.Entry{
+		Time:    time.Date(2020, 11, 2, 12, 0, 0, 0, time.FixedZone("EET", 2*60*60)),
+		Level:   logrus.ErrorLevel,
+		Message: "could not find user",
+		Data: logrus.Fields{
+			"id":           "123",
+			"error":        errors.New("not found"),
+			FieldErrorCode: "NOT_FOUND",
+			"message":      "custom",
+			FieldVersion:   "0.0.1",
+		},
+	}
+	data, err := f.Format(&entry)
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	err = json.Unmarshal(data, &doc)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"@timestamp":      "2020-11-02T10:00:00Z",
+		"log.level":       "error",
+		"message":         "could not find user",
+		"ecs.version":     ecsVersion,
+		"service.name":    "user",
+		"service.version": "0.0.1",
+		"error.message":   "not found",
+		"error.code":      "NOT_FOUND",
+		"fields.message":  "custom",
+		"id":              "123",
+	}, doc)
+}
+
+func Test_NewFileLoggerWithFormat(t *testing.T) {
+	t.Run("unknown format error", func(t *testing.T) {
+		_, err := NewFileLogger("./testlog/format", "test.log", os.ModePerm, WithFormat("xml"))
+		require.Error(t, err)
+		require.EqualError(t, err, "unknown log format xml")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		l, err := NewFileLogger("./testlog/format", "test.log", os.ModePerm, WithFormat(FormatECS), WithService("user", "0.0.1"))
+		require.NoError(t, err)
+		defer func() {
+			err := os.RemoveAll("./testlog")
+			require.NoError(t, err)
+		}()
+		l.Named("storage").Info("hello")
+		err = l.Close()
+		require.NoError(t, err)
+		data, err := ioutil.ReadFile("./testlog/format/test.log")
+		require.NoError(t, err)
+		var doc map[string]interface{}
+		err = json.NewDecoder(bytes.NewReader(data)).Decode(&doc)
+		require.NoError(t, err)
+		require.Equal(t, "user", doc["service.name"])
+		require.Equal(t, "0.0.1", doc["service.version"])
+		require.Equal(t, "hello", doc["message"])
+	})
+}
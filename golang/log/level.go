@@ -0,0 +1,489 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// FieldComponent is the field with the name of the sub-logger which writes the entry.
+const FieldComponent = "component"
+
+// notifySignal subscribes the channel to the signals, it's replaced in the tests
+// to know when the watcher is subscribed.
+var notifySignal = signal.Notify
+
+// levelRegistry keeps track of the root logger and all the named sub-loggers
+// so their levels could be changed at runtime. The loggers share the output
+// and the formatter owned by the registry.
+type levelRegistry struct {
+	mu        sync.Mutex
+	loggers   map[string]*logrus.Logger
+	names     sync.Map // *logrus.Logger to its name.
+	reverts   map[string]*levelRevert
+	overrides map[string]logrus.Level
+	out       *sharedWriter
+	formatter *sharedFormatter
+}
+
+// sharedWriter serializes the writes of the root logger and all the sub-loggers,
+// since every logrus logger locks only its own writes.
+type sharedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// sharedFormatter lets the formatter of the root logger and all the sub-loggers be replaced at once.
+type sharedFormatter struct {
+	mu sync.RWMutex
+	f  logrus.Formatter
+}
+
+// levelRevert represents a pending level restoration.
+type levelRevert struct {
+	timer    *time.Timer
+	gen      int
+	previous map[string]logrus.Level
+}
+
+// LevelRequest represents runtime level change request.
+type LevelRequest struct {
+	Logger string `json:"logger,omitempty"`
+	Level  string `json:"level"`
+	Revert string `json:"revert,omitempty"`
+}
+
+// LevelState represents current levels of the logger and its sub-loggers.
+type LevelState struct {
+	Level     string            `json:"level"`
+	Loggers   map[string]string `json:"loggers,omitempty"`
+	Overrides map[string]string `json:"overrides,omitempty"`
+}
+
+// newLevelRegistry creates the registry of the root logger.
+// The output and the formatter of the root logger are replaced by the shared ones.
+func newLevelRegistry(root *logrus.Logger) *levelRegistry {
+	r := levelRegistry{
+		loggers:   map[string]*logrus.Logger{"": root},
+		reverts:   make(map[string]*levelRevert),
+		out:       &sharedWriter{w: root.Out},
+		formatter: &sharedFormatter{f: root.Formatter},
+	}
+	root.Out = r.out
+	root.Formatter = r.formatter
+	return &r
+}
+
+// Write writes the data into the output.
+func (w *sharedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// Flush writes all the buffered data if the output is buffered.
+func (w *sharedWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if f, ok := w.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close closes the output if it's closable.
+func (w *sharedWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if c, ok := w.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// update replaces the output by the result of fn called with the current one.
+func (w *sharedWriter) update(fn func(io.Writer) io.Writer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.w = fn(w.w)
+}
+
+// Format formats the entry by the current formatter.
+func (f *sharedFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	f.mu.RLock()
+	formatter := f.f
+	f.mu.RUnlock()
+	return formatter.Format(entry)
+}
+
+func (f *sharedFormatter) set(formatter logrus.Formatter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.f = formatter
+}
+
+func (r *levelRegistry) child(name string, parent *logrus.Logger) *logrus.Logger {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if logger, ok := r.loggers[name]; ok {
+		return logger
+	}
+
+	logger := &logrus.Logger{
+		Out:          parent.Out,
+		Hooks:        parent.Hooks,
+		Formatter:    parent.Formatter,
+		ReportCaller: parent.ReportCaller,
+		Level:        parent.GetLevel(),
+		ExitFunc:     parent.ExitFunc,
+	}
+	if level, ok := r.override(name); ok {
+		logger.SetLevel(level)
+	}
+	r.loggers[name] = logger
+	r.names.Store(logger, name)
+	return logger
+}
+
+// override returns the level of the longest prefix override matching the name.
+func (r *levelRegistry) override(name string) (logrus.Level, bool) {
+	var (
+		level   logrus.Level
+		matched = -1
+	)
+	for prefix, l := range r.overrides {
+		if len(prefix) > matched && matchPrefix(name, prefix) {
+			level = l
+			matched = len(prefix)
+		}
+	}
+	return level, matched >= 0
+}
+
+// setOverrides replaces the prefix level overrides. The sub-loggers which
+// are not matched by the overrides anymore get the root logger level.
+func (r *levelRegistry) setOverrides(overrides map[string]logrus.Level) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := r.overrides
+	r.overrides = overrides
+	for name, logger := range r.loggers {
+		if name == "" {
+			continue
+		}
+		if level, ok := r.override(name); ok {
+			logger.SetLevel(level)
+			continue
+		}
+		for prefix := range previous {
+			if matchPrefix(name, prefix) {
+				logger.SetLevel(r.loggers[""].GetLevel())
+				break
+			}
+		}
+	}
+}
+
+// matchPrefix checks if the prefix matches the name by whole name segments,
+// e.g. "storage" matches "storage" and "storage.mongo" but not "storages".
+func matchPrefix(name, prefix string) bool {
+	return name == prefix || strings.HasPrefix(name, prefix+".")
+}
+
+// componentHook adds the component field to the entries of the named sub-loggers.
+// The hook is shared by all the loggers since they share the hooks.
+type componentHook struct {
+	levels *levelRegistry
+}
+
+// Levels returns all the levels.
+func (h *componentHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire adds the component field to the entry of the named sub-logger.
+func (h *componentHook) Fire(entry *logrus.Entry) error {
+	if name, ok := h.levels.names.Load(entry.Logger); ok {
+		entry.Data[FieldComponent] = name
+	}
+	return nil
+}
+
+// each calls fn for the root logger and all the sub-loggers.
+func (r *levelRegistry) each(fn func(*logrus.Logger)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, logger := range r.loggers {
+		fn(logger)
+	}
+}
+
+// targets returns the loggers affected by the change of the provided name.
+// An empty name means the root logger and all the sub-loggers.
+func (r *levelRegistry) targets(name string) (map[string]*logrus.Logger, error) {
+	if name == "" {
+		return r.loggers, nil
+	}
+	logger, ok := r.loggers[name]
+	if !ok {
+		return nil, errors.Errorf("unknown logger %s", name)
+	}
+	return map[string]*logrus.Logger{name: logger}, nil
+}
+
+// set changes the level of the loggers by the provided name.
+// If keep is true, the previous levels are kept to be restored later,
+// a positive revert duration restores them automatically.
+func (r *levelRegistry) set(name string, level logrus.Level, revert time.Duration, keep bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loggers, err := r.targets(name)
+	if err != nil {
+		return err
+	}
+
+	// keep the levels from before the first change to restore them later.
+	pending, ok := r.reverts[name]
+	if ok {
+		if pending.timer != nil {
+			pending.timer.Stop()
+		}
+	} else {
+		pending = &levelRevert{
+			previous: make(map[string]logrus.Level, len(loggers)),
+		}
+		for n, logger := range loggers {
+			pending.previous[n] = logger.GetLevel()
+		}
+	}
+
+	for _, logger := range loggers {
+		logger.SetLevel(level)
+	}
+	if name == "" {
+		r.applyOverrides()
+	}
+
+	if !keep {
+		delete(r.reverts, name)
+		return nil
+	}
+
+	// the generation protects from restoring by a timer which has been
+	// already fired but not stopped in time by the next change.
+	pending.gen++
+	pending.timer = nil
+	if revert > 0 {
+		gen := pending.gen
+		pending.timer = time.AfterFunc(revert, func() {
+			r.restore(name, gen)
+		})
+	}
+	r.reverts[name] = pending
+	return nil
+}
+
+// restore restores the levels changed by the provided name.
+// A positive generation restores the levels only if they were not changed since.
+func (r *levelRegistry) restore(name string, gen int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pending, ok := r.reverts[name]
+	if !ok || (gen > 0 && gen != pending.gen) {
+		return false
+	}
+	if pending.timer != nil {
+		pending.timer.Stop()
+	}
+	delete(r.reverts, name)
+
+	for n, level := range pending.previous {
+		if logger, ok := r.loggers[n]; ok {
+			logger.SetLevel(level)
+		}
+	}
+	if name == "" {
+		r.applyOverrides()
+	}
+	return true
+}
+
+// applyOverrides sets the levels of the sub-loggers matched by the prefix overrides,
+// so the change of all the loggers keeps the overridden levels.
+func (r *levelRegistry) applyOverrides() {
+	for name, logger := range r.loggers {
+		if name == "" {
+			continue
+		}
+		if level, ok := r.override(name); ok {
+			logger.SetLevel(level)
+		}
+	}
+}
+
+func (r *levelRegistry) state() LevelState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state := LevelState{
+		Level: r.loggers[""].GetLevel().String(),
+	}
+	for name, logger := range r.loggers {
+		if name == "" {
+			continue
+		}
+		if state.Loggers == nil {
+			state.Loggers = make(map[string]string, len(r.loggers)-1)
+		}
+		state.Loggers[name] = logger.GetLevel().String()
+	}
+	for prefix, level := range r.overrides {
+		if state.Overrides == nil {
+			state.Overrides = make(map[string]string, len(r.overrides))
+		}
+		state.Overrides[prefix] = level.String()
+	}
+	return state
+}
+
+// ParseLevelOverrides parses the prefix level overrides in the
+// "prefix=level,prefix=level" format, e.g. "storage=debug,service=warn".
+func ParseLevelOverrides(value string) (map[string]logrus.Level, error) {
+	overrides := make(map[string]logrus.Level)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		prefix := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || prefix == "" {
+			return nil, errors.Errorf("invalid level override %s", pair)
+		}
+		level, err := logrus.ParseLevel(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid level override %s", pair)
+		}
+		overrides[prefix] = level
+	}
+	return overrides, nil
+}
+
+// ChangeLevel changes the level of the sub-logger with the provided name.
+// An empty name changes the level of the root logger and all the sub-loggers
+// except the ones matched by the prefix overrides.
+// If revert is positive, the previous levels are restored after it expires.
+func (l *Logger) ChangeLevel(name string, level logrus.Level, revert time.Duration) error {
+	return l.levels.set(name, level, revert, revert > 0)
+}
+
+// SetLevelOverrides replaces the levels of the sub-loggers by their name prefixes.
+// The longest matching prefix wins, e.g. "storage" applies to "storage.mongo"
+// unless there is "storage.mongo" override. The overrides apply to the sub-loggers
+// created later as well. The sub-loggers which are not matched by the new
+// overrides anymore get the root logger level.
+func (l *Logger) SetLevelOverrides(overrides map[string]logrus.Level) {
+	l.levels.setOverrides(overrides)
+}
+
+// RestoreLevel restores the levels changed by the ChangeLevel call with the
+// provided name and a positive revert duration.
+// Returns false if there is nothing to restore.
+func (l *Logger) RestoreLevel(name string) bool {
+	return l.levels.restore(name, 0)
+}
+
+// Levels returns current levels of the logger and all the sub-loggers.
+func (l *Logger) Levels() LevelState {
+	return l.levels.state()
+}
+
+// Apply applies runtime level change request.
+func (l *Logger) Apply(req LevelRequest) error {
+	level, err := logrus.ParseLevel(req.Level)
+	if err != nil {
+		return err
+	}
+
+	var revert time.Duration
+	if req.Revert != "" {
+		revert, err = time.ParseDuration(req.Revert)
+		if err != nil {
+			return errors.Wrap(err, "could not parse revert duration")
+		}
+	}
+
+	return l.ChangeLevel(req.Logger, level, revert)
+}
+
+// WatchLevelSignal switches all the loggers to the provided level on the signal
+// receiving, SIGUSR1 by default. The next signal restores previous levels.
+// If revert is positive, previous levels are restored automatically after it expires.
+// Blocks until the context is done.
+func (l *Logger) WatchLevelSignal(ctx context.Context, level logrus.Level, revert time.Duration, sig ...os.Signal) {
+	if len(sig) == 0 {
+		// signal.Notify without signals subscribes to all of them including SIGINT and SIGTERM.
+		sig = []os.Signal{syscall.SIGUSR1}
+	}
+	c := make(chan os.Signal, 1)
+	notifySignal(c, sig...)
+	defer signal.Stop(c)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c:
+			if l.RestoreLevel("") {
+				continue
+			}
+			// the root logger always exists so there is no error to handle.
+			_ = l.levels.set("", level, revert, true)
+		}
+	}
+}
+
+// LevelHandler returns http handler to control logger levels at runtime.
+// GET returns current levels, PUT changes a level by LevelRequest body,
+// DELETE restores previous levels of the logger provided in the "logger" query parameter.
+func (l *Logger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var req LevelRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, errors.Wrap(err, "could not parse request").Error(), http.StatusBadRequest)
+				return
+			}
+			if err := l.Apply(req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		case http.MethodDelete:
+			l.RestoreLevel(r.URL.Query().Get("logger"))
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(l.Levels())
+	})
+}
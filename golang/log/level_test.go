@@ -0,0 +1,354 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_ChangeLevel(t *testing.T) {
+	t.Run("unknown logger error", func(t *testing.T) {
+		l := New(logrus.New())
+		err := l.ChangeLevel("unknown", logrus.DebugLevel, 0)
+		require.Error(t, err)
+		require.EqualError(t, err, "unknown logger unknown")
+	})
+	t.Run("global level", func(t *testing.T) {
+		l := New(logrus.New())
+		child := l.Named("storage")
+		err := l.ChangeLevel("", logrus.DebugLevel, 0)
+		require.NoError(t, err)
+		require.Equal(t, logrus.DebugLevel, l.GetLevel())
+		require.Equal(t, logrus.DebugLevel, child.GetLevel())
+		require.False(t, l.RestoreLevel(""))
+	})
+	t.Run("named level", func(t *testing.T) {
+		l := New(logrus.New())
+		child := l.Named("storage")
+		err := l.ChangeLevel("storage", logrus.DebugLevel, 0)
+		require.NoError(t, err)
+		require.Equal(t, logrus.InfoLevel, l.GetLevel())
+		require.Equal(t, logrus.DebugLevel, child.GetLevel())
+		require.Equal(t, LevelState{
+			Level:   "info",
+			Loggers: map[string]string{"storage": "debug"},
+		}, l.Levels())
+	})
+	t.Run("automatic revert", func(t *testing.T) {
+		l := New(logrus.New())
+		child := l.Named("storage")
+		err := l.ChangeLevel("storage", logrus.DebugLevel, 20*time.Millisecond)
+		require.NoError(t, err)
+		err = l.ChangeLevel("storage", logrus.TraceLevel, 20*time.Millisecond)
+		require.NoError(t, err)
+		require.Equal(t, logrus.TraceLevel, child.GetLevel())
+		require.Eventually(t, func() bool {
+			return child.GetLevel() == logrus.InfoLevel
+		}, time.Second, 5*time.Millisecond)
+	})
+	t.Run("manual restore", func(t *testing.T) {
+		l := New(logrus.New())
+		err := l.ChangeLevel("", logrus.ErrorLevel, time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, logrus.ErrorLevel, l.GetLevel())
+		require.True(t, l.RestoreLevel(""))
+		require.Equal(t, logrus.InfoLevel, l.GetLevel())
+	})
+}
+
+func TestLogger_SetLevelOverrides(t *testing.T) {
+	l := New(logrus.New())
+	storage := l.Named("storage")
+	mongo := storage.Named("mongo")
+	rpc := l.Named("service")
+	other := l.Named("storages")
+
+	l.SetLevelOverrides(map[string]logrus.Level{
+		"storage":       logrus.DebugLevel,
+		"storage.mongo": logrus.TraceLevel,
+		"service":       logrus.WarnLevel,
+	})
+	require.Equal(t, logrus.InfoLevel, l.GetLevel())
+	require.Equal(t, logrus.DebugLevel, storage.GetLevel())
+	require.Equal(t, logrus.TraceLevel, mongo.GetLevel())
+	require.Equal(t, logrus.WarnLevel, rpc.GetLevel())
+	require.Equal(t, logrus.InfoLevel, other.GetLevel())
+	// the sub-loggers created later get the overrides too.
+	require.Equal(t, logrus.DebugLevel, storage.Named("redis").GetLevel())
+	require.Equal(t, map[string]string{
+		"storage":       "debug",
+		"storage.mongo": "trace",
+		"service":       "warning",
+	}, l.Levels().Overrides)
+
+	l.SetLevelOverrides(map[string]logrus.Level{
+		"storage": logrus.ErrorLevel,
+	})
+	require.Equal(t, logrus.ErrorLevel, storage.GetLevel())
+	require.Equal(t, logrus.ErrorLevel, mongo.GetLevel())
+	require.Equal(t, logrus.InfoLevel, rpc.GetLevel())
+
+	// the change of all the loggers keeps the overridden levels.
+	err := l.ChangeLevel("", logrus.DebugLevel, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, logrus.DebugLevel, l.GetLevel())
+	require.Equal(t, logrus.DebugLevel, rpc.GetLevel())
+	require.Equal(t, logrus.ErrorLevel, storage.GetLevel())
+	require.Equal(t, logrus.ErrorLevel, mongo.GetLevel())
+
+	l.SetLevelOverrides(map[string]logrus.Level{
+		"storage.mongo": logrus.TraceLevel,
+	})
+	require.True(t, l.RestoreLevel(""))
+	require.Equal(t, logrus.InfoLevel, l.GetLevel())
+	require.Equal(t, logrus.InfoLevel, rpc.GetLevel())
+	require.Equal(t, logrus.TraceLevel, mongo.GetLevel())
+}
+
+func Test_ParseLevelOverrides(t *testing.T) {
+	tt := []struct {
+		name   string
+		value  string
+		exp    map[string]logrus.Level
+		expErr string
+	}{
+		{
+			name:  "empty",
+			value: "",
+			exp:   map[string]logrus.Level{},
+		},
+		{
+			name:  "all ok",
+			value: "storage=debug, service=warn",
+			exp: map[string]logrus.Level{
+				"storage": logrus.DebugLevel,
+				"service": logrus.WarnLevel,
+			},
+		},
+		{
+			name:   "missing level error",
+			value:  "storage",
+			expErr: "invalid level override storage",
+		},
+		{
+			name:   "invalid level error",
+			value:  "storage=loud",
+			expErr: "invalid level override storage=loud: not a valid logrus Level: \"loud\"",
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			overrides, err := ParseLevelOverrides(tc.value)
+			if tc.expErr != "" {
+				require.Error(t, err)
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, overrides)
+		})
+	}
+}
+
+func TestLogger_Named(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := newTestLogger(buf)
+	l.Info("root")
+	require.NotContains(t, buf.String(), FieldComponent)
+
+	buf.Reset()
+	mongo := l.Named("storage").Named("mongo")
+	require.Equal(t, "storage.mongo", mongo.Name())
+	mongo.WithField("id", 1).Info("connected")
+	require.Contains(t, buf.String(), `"component":"storage.mongo"`)
+}
+
+func TestLogger_Apply(t *testing.T) {
+	tt := []struct {
+		name   string
+		req    LevelRequest
+		expErr string
+	}{
+		{
+			name:   "invalid level error",
+			req:    LevelRequest{Level: "loud"},
+			expErr: "not a valid logrus Level: \"loud\"",
+		},
+		{
+			name:   "invalid revert error",
+			req:    LevelRequest{Level: "debug", Revert: "soon"},
+			expErr: "could not parse revert duration: time: invalid duration \"soon\"",
+		},
+		{
+			name: "all ok",
+			req:  LevelRequest{Level: "debug", Revert: "1h"},
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			l := New(logrus.New())
+			err := l.Apply(tc.req)
+			if tc.expErr != "" {
+				require.Error(t, err)
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, logrus.DebugLevel, l.GetLevel())
+		})
+	}
+}
+
+func TestLogger_WatchLevelSignal(t *testing.T) {
+	l := New(logrus.New())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ready := notifyReady(t)
+	done := make(chan struct{})
+	go func() {
+		// SIGUSR1 by default.
+		l.WatchLevelSignal(ctx, logrus.DebugLevel, 0)
+		close(done)
+	}()
+	<-ready
+
+	err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return l.GetLevel() == logrus.DebugLevel
+	}, time.Second, 5*time.Millisecond)
+
+	err = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return l.GetLevel() == logrus.InfoLevel
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestLogger_LevelHandler(t *testing.T) {
+	tt := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		expCode int
+		expBody string
+	}{
+		{
+			name:    "get levels",
+			method:  http.MethodGet,
+			target:  "/",
+			expCode: http.StatusOK,
+			expBody: `{"level":"info","loggers":{"storage":"info"}}`,
+		},
+		{
+			name:    "invalid body error",
+			method:  http.MethodPut,
+			target:  "/",
+			body:    "invalid",
+			expCode: http.StatusBadRequest,
+			expBody: "could not parse request: invalid character 'i' looking for beginning of value",
+		},
+		{
+			name:    "invalid level error",
+			method:  http.MethodPut,
+			target:  "/",
+			body:    `{"logger":"storage","level":"loud"}`,
+			expCode: http.StatusBadRequest,
+			expBody: `not a valid logrus Level: "loud"`,
+		},
+		{
+			name:    "change level",
+			method:  http.MethodPut,
+			target:  "/",
+			body:    `{"logger":"storage","level":"debug","revert":"1h"}`,
+			expCode: http.StatusOK,
+			expBody: `{"level":"info","loggers":{"storage":"debug"}}`,
+		},
+		{
+			name:    "restore level",
+			method:  http.MethodDelete,
+			target:  "/?logger=storage",
+			expCode: http.StatusOK,
+			expBody: `{"level":"info","loggers":{"storage":"info"}}`,
+		},
+		{
+			name:    "method not allowed error",
+			method:  http.MethodPatch,
+			target:  "/",
+			expCode: http.StatusMethodNotAllowed,
+			expBody: http.StatusText(http.StatusMethodNotAllowed),
+		},
+	}
+	l := New(logrus.New())
+	l.Named("storage")
+	handler := l.LevelHandler()
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tc.method, tc.target, bytes.NewBufferString(tc.body))
+			handler.ServeHTTP(w, r)
+			require.Equal(t, tc.expCode, w.Code)
+			require.Equal(t, tc.expBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
+func TestLogger_NamedShared(t *testing.T) {
+	l := New(logrus.New())
+	storage := l.Named("storage")
+	var buf bytes.Buffer
+	l.SetOutput(&buf)
+	l.SetFormatter(&logrus.JSONFormatter{DisableTimestamp: true})
+	l.SetLevel(logrus.DebugLevel)
+	require.Equal(t, logrus.DebugLevel, storage.GetLevel())
+
+	// the root logger and the sub-logger write into the same output concurrently.
+	var wg sync.WaitGroup
+	for _, logger := range []*Logger{l, storage} {
+		wg.Add(1)
+		go func(logger *Logger) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				logger.Debug("hello")
+			}
+		}(logger)
+	}
+	wg.Wait()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Equal(t, 200, len(lines))
+	require.Contains(t, lines, `{"component":"storage","level":"debug","msg":"hello"}`)
+
+	storage.SetLevel(logrus.ErrorLevel)
+	require.Equal(t, logrus.ErrorLevel, storage.GetLevel())
+	require.Equal(t, logrus.DebugLevel, l.GetLevel())
+}
+
+// notifyReady returns the channel closed when the signal watcher is subscribed,
+// so the signal sent after it doesn't terminate the test process.
+func notifyReady(t *testing.T) <-chan struct{} {
+	ready := make(chan struct{})
+	notifySignal = func(c chan<- os.Signal, sig ...os.Signal) {
+		signal.Notify(c, sig...)
+		close(ready)
+	}
+	t.Cleanup(func() { notifySignal = signal.Notify })
+	return ready
+}
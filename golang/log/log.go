@@ -1,6 +1,7 @@
 package log
 
 import (
+	"io"
 	"os"
 	"path"
 
@@ -11,23 +12,156 @@ import (
 // Logger represents geneic logger instance.
 type Logger struct {
 	*logrus.Logger
+	name   string
+	levels *levelRegistry
+	file   *FileWriter
+	// service name and version from the service contract.
+	service string
+	version string
+}
+
+// Option represents logger option.
+type Option func(*options)
+
+type options struct {
+	async   *AsyncConfig
+	format  string
+	service string
+	version string
+}
+
+// WithAsync makes the logger write into the file asynchronously.
+// Logger.Close must be called before the exit to flush the buffered lines.
+func WithAsync(cfg AsyncConfig) Option {
+	return func(o *options) {
+		o.async = &cfg
+	}
+}
+
+// WithFormat sets the log format: json (default), logfmt or ecs.
+func WithFormat(format string) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+// WithService sets the service name and version added to the log entries.
+func WithService(name, version string) Option {
+	return func(o *options) {
+		o.service = name
+		o.version = version
+	}
+}
+
+// New creates new logger instance on top of the provided logrus logger.
+// The entries of the named sub-loggers get the component field.
+func New(logger *logrus.Logger) *Logger {
+	levels := newLevelRegistry(logger)
+	logger.AddHook(&componentHook{levels: levels})
+	return &Logger{
+		Logger: logger,
+		levels: levels,
+	}
 }
 
 // NewFileLogger creates new file logger.
-func NewFileLogger(logFolder, logFile string, perm os.FileMode) (*Logger, error) {
+func NewFileLogger(logFolder, logFile string, perm os.FileMode, opts ...Option) (*Logger, error) {
+	var o options
+	for i := range opts {
+		opts[i](&o)
+	}
+
+	formatter, err := NewFormatter(o.format, o.service, o.version)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := os.MkdirAll(logFolder, perm); err != nil && err != os.ErrExist {
 		return nil, errors.Wrap(err, "could not create log folder")
 	}
 
 	logPath := path.Join(logFolder, logFile)
-	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_RDWR, perm)
+	f, err := OpenFileWriter(logPath, perm)
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not open log file %s", logPath)
 	}
 
+	var out io.Writer = f
+	if o.async != nil {
+		out = NewAsyncWriter(f, *o.async)
+	}
+
 	logger := logrus.New()
-	logger.SetFormatter(&logrus.JSONFormatter{})
-	logger.SetOutput(f)
+	logger.SetFormatter(formatter)
+	logger.SetOutput(out)
+
+	l := New(logger)
+	l.file = f
+	l.service = o.service
+	l.version = o.version
+	return l, nil
+}
+
+// Name returns the name of the logger. The root logger has an empty name.
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// Named returns a sub-logger with the provided name, e.g. "storage.mongo".
+// The sub-logger shares output, formatter and hooks with its parent
+// but has its own level, which can be changed at runtime by the name
+// or by a prefix override. Its entries have the component field with the name.
+func (l *Logger) Named(name string) *Logger {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	return &Logger{
+		Logger:  l.levels.child(name, l.Logger),
+		name:    name,
+		levels:  l.levels,
+		file:    l.file,
+		service: l.service,
+		version: l.version,
+	}
+}
 
-	return &Logger{logger}, nil
+// SetOutput sets the output of the root logger and all the sub-loggers.
+// The sinks added by AddSink are kept.
+func (l *Logger) SetOutput(out io.Writer) {
+	l.levels.out.update(func(current io.Writer) io.Writer {
+		if mw, ok := current.(*multiWriter); ok {
+			writers := append([]io.Writer{out}, mw.writers[1:]...)
+			return &multiWriter{writers: writers}
+		}
+		return out
+	})
+}
+
+// SetFormatter sets the formatter of the root logger and all the sub-loggers.
+// The redaction and the sampling stay applied.
+func (l *Logger) SetFormatter(formatter logrus.Formatter) {
+	l.levels.formatter.set(formatter)
+}
+
+// SetLevel sets the level of the logger. The level of the root logger is set
+// to all the sub-loggers as well except the ones matched by the prefix overrides.
+func (l *Logger) SetLevel(level logrus.Level) {
+	_ = l.levels.set(l.name, level, 0, false)
+}
+
+// Flush writes all the buffered log data if the logger output is buffered.
+func (l *Logger) Flush() error {
+	if f, ok := l.Out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close flushes the buffered log data and closes the logger output.
+// It's supposed to be called from the shutdown hook.
+func (l *Logger) Close() error {
+	if c, ok := l.Out.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
 }
@@ -0,0 +1,141 @@
+// Package logtest provides in-memory logger and assertion helpers for testing.
+package logtest
+
+import (
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/open-Q/common/golang/log"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// Entry represents recorded log entry.
+type Entry struct {
+	Time    time.Time
+	Level   logrus.Level
+	Message string
+	Fields  logrus.Fields
+}
+
+// Recorder records log entries of the logger.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// New creates new in-memory logger with all the levels enabled and its recorder.
+func New() (*log.Logger, *Recorder) {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.SetLevel(logrus.TraceLevel)
+
+	// the recorder is added after the logger hooks to record the fields they add.
+	l := log.New(logger)
+	r := Recorder{}
+	logger.AddHook(&r)
+
+	return l, &r
+}
+
+// Levels returns all the levels to record.
+func (r *Recorder) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire records the log entry.
+func (r *Recorder) Fire(entry *logrus.Entry) error {
+	fields := make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		fields[k] = v
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, Entry{
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Message: entry.Message,
+		Fields:  fields,
+	})
+	return nil
+}
+
+// Entries returns all the recorded entries.
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]Entry, len(r.entries))
+	copy(entries, r.entries)
+	return entries
+}
+
+// Last returns the last recorded entry.
+func (r *Recorder) Last() (Entry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) == 0 {
+		return Entry{}, false
+	}
+	return r.entries[len(r.entries)-1], true
+}
+
+// Find returns the recorded entries which satisfy the provided condition.
+func (r *Recorder) Find(fn func(Entry) bool) []Entry {
+	var found []Entry
+	for _, entry := range r.Entries() {
+		if fn(entry) {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+// Reset removes all the recorded entries.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// AssertMessage asserts that an entry with the provided message has been logged.
+func (r *Recorder) AssertMessage(t testing.TB, msg string) {
+	t.Helper()
+	if len(r.Find(func(e Entry) bool { return e.Message == msg })) == 0 {
+		require.Failf(t, "message is not logged", "expected message: %q\nlogged entries: %v", msg, r.Entries())
+	}
+}
+
+// AssertField asserts that an entry with the provided field value has been logged.
+func (r *Recorder) AssertField(t testing.TB, key string, value interface{}) {
+	t.Helper()
+	found := r.Find(func(e Entry) bool {
+		v, ok := e.Fields[key]
+		return ok && reflect.DeepEqual(v, value)
+	})
+	if len(found) == 0 {
+		require.Failf(t, "field is not logged", "expected field: %s=%v\nlogged entries: %v", key, value, r.Entries())
+	}
+}
+
+// AssertLevel asserts that an entry with the provided level has been logged.
+func (r *Recorder) AssertLevel(t testing.TB, level logrus.Level) {
+	t.Helper()
+	if len(r.Find(func(e Entry) bool { return e.Level == level })) == 0 {
+		require.Failf(t, "level is not logged", "expected level: %s\nlogged entries: %v", level, r.Entries())
+	}
+}
+
+// AssertNoErrors asserts that no entries with the error level or above have been logged.
+func (r *Recorder) AssertNoErrors(t testing.TB) {
+	t.Helper()
+	found := r.Find(func(e Entry) bool { return e.Level <= logrus.ErrorLevel })
+	if len(found) != 0 {
+		require.Failf(t, "errors are logged", "logged errors: %v", found)
+	}
+}
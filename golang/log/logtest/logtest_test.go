@@ -0,0 +1,119 @@
+package logtest
+
+import (
+	"testing"
+
+	"github.com/open-Q/common/golang/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_New(t *testing.T) {
+	l, r := New()
+	require.NotNil(t, l)
+	require.NotNil(t, r)
+	_, ok := r.Last()
+	require.False(t, ok)
+
+	l.Trace("trace message")
+	l.Named("storage").WithField("id", 123).Info("found")
+	entries := r.Entries()
+	require.Equal(t, 2, len(entries))
+	require.Equal(t, logrus.TraceLevel, entries[0].Level)
+	last, ok := r.Last()
+	require.True(t, ok)
+	require.Equal(t, "found", last.Message)
+	require.Equal(t, logrus.Fields{"id": 123, log.FieldComponent: "storage"}, last.Fields)
+
+	r.Reset()
+	require.Empty(t, r.Entries())
+}
+
+func TestRecorder_Assert(t *testing.T) {
+	tt := []struct {
+		name   string
+		assert func(t testing.TB, r *Recorder)
+		expOk  bool
+	}{
+		{
+			name: "message",
+			assert: func(t testing.TB, r *Recorder) {
+				r.AssertMessage(t, "user created")
+			},
+			expOk: true,
+		},
+		{
+			name: "missing message",
+			assert: func(t testing.TB, r *Recorder) {
+				r.AssertMessage(t, "user deleted")
+			},
+		},
+		{
+			name: "field",
+			assert: func(t testing.TB, r *Recorder) {
+				r.AssertField(t, "id", "123")
+			},
+			expOk: true,
+		},
+		{
+			name: "missing field value",
+			assert: func(t testing.TB, r *Recorder) {
+				r.AssertField(t, "id", 123)
+			},
+		},
+		{
+			name: "level",
+			assert: func(t testing.TB, r *Recorder) {
+				r.AssertLevel(t, logrus.WarnLevel)
+			},
+			expOk: true,
+		},
+		{
+			name: "missing level",
+			assert: func(t testing.TB, r *Recorder) {
+				r.AssertLevel(t, logrus.DebugLevel)
+			},
+		},
+		{
+			name: "no errors",
+			assert: func(t testing.TB, r *Recorder) {
+				r.AssertNoErrors(t)
+			},
+			expOk: true,
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			l, r := New()
+			l.WithField("id", "123").Info("user created")
+			l.Warn("slow query")
+			ft := fakeT{TB: t}
+			tc.assert(&ft, r)
+			require.Equal(t, !tc.expOk, ft.failed)
+		})
+	}
+
+	t.Run("errors are logged", func(t *testing.T) {
+		l, r := New()
+		l.WithError(errors.New("some error")).Error("could not create user")
+		ft := fakeT{TB: t}
+		r.AssertNoErrors(&ft)
+		require.True(t, ft.failed)
+	})
+}
+
+// fakeT records the failure instead of failing the test.
+type fakeT struct {
+	testing.TB
+	failed bool
+}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.failed = true
+}
+
+func (t *fakeT) FailNow() {
+	t.failed = true
+}
@@ -0,0 +1,132 @@
+package log
+
+import (
+	"context"
+
+	"github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/server"
+	"github.com/sirupsen/logrus"
+)
+
+// MicroLogger represents go-micro logger adapter on top of the Logger.
+// The level is controlled by the Logger, so go-micro level options are ignored.
+type MicroLogger struct {
+	logger *Logger
+	fields logrus.Fields
+	opts   logger.Options
+}
+
+// NewMicroLogger creates new go-micro logger adapter.
+func NewMicroLogger(l *Logger) *MicroLogger {
+	return &MicroLogger{
+		logger: l,
+		fields: logrus.Fields{},
+	}
+}
+
+// Init initialises the adapter options.
+func (m *MicroLogger) Init(opts ...logger.Option) error {
+	for i := range opts {
+		opts[i](&m.opts)
+	}
+	m.fields = mergeFields(m.fields, m.opts.Fields)
+	return nil
+}
+
+// Options returns the adapter options with the actual level of the Logger.
+func (m *MicroLogger) Options() logger.Options {
+	opts := m.opts
+	opts.Level = toMicroLevel(m.logger.GetLevel())
+	opts.Fields = m.fields
+	opts.Out = m.logger.Out
+	return opts
+}
+
+// Fields returns new adapter which always logs the provided fields.
+func (m *MicroLogger) Fields(fields map[string]interface{}) logger.Logger {
+	return &MicroLogger{
+		logger: m.logger,
+		fields: mergeFields(m.fields, fields),
+		opts:   m.opts,
+	}
+}
+
+// Log writes a log entry.
+func (m *MicroLogger) Log(level logger.Level, v ...interface{}) {
+	m.logger.WithFields(m.fields).Log(toLogrusLevel(level), v...)
+}
+
+// Logf writes a formatted log entry.
+func (m *MicroLogger) Logf(level logger.Level, format string, v ...interface{}) {
+	m.logger.WithFields(m.fields).Logf(toLogrusLevel(level), format, v...)
+}
+
+// String returns the name of the adapter.
+func (m *MicroLogger) String() string {
+	return "logrus"
+}
+
+// HandlerWrapper returns go-micro handler wrapper, which adds the request fields
+// to the handler context and returns a recovered panic as internal server error.
+func (l *Logger) HandlerWrapper() server.HandlerWrapper {
+	return func(fn server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) (err error) {
+			ctx = ContextWithFields(ctx, logrus.Fields{
+				"endpoint": req.Endpoint(),
+			})
+			defer func() {
+				if _, ok := err.(*PanicError); ok {
+					err = errors.InternalServerError(req.Service(), "%s", err.Error())
+				}
+			}()
+			defer l.Recover(ctx, &err)
+			return fn(ctx, req, rsp)
+		}
+	}
+}
+
+func mergeFields(fields logrus.Fields, extra map[string]interface{}) logrus.Fields {
+	merged := make(logrus.Fields, len(fields)+len(extra))
+	for k, v := range fields {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+func toLogrusLevel(level logger.Level) logrus.Level {
+	switch level {
+	case logger.TraceLevel:
+		return logrus.TraceLevel
+	case logger.DebugLevel:
+		return logrus.DebugLevel
+	case logger.WarnLevel:
+		return logrus.WarnLevel
+	case logger.ErrorLevel:
+		return logrus.ErrorLevel
+	case logger.FatalLevel:
+		return logrus.FatalLevel
+	default:
+		return logrus.InfoLevel
+	}
+}
+
+func toMicroLevel(level logrus.Level) logger.Level {
+	switch level {
+	case logrus.TraceLevel:
+		return logger.TraceLevel
+	case logrus.DebugLevel:
+		return logger.DebugLevel
+	case logrus.InfoLevel:
+		return logger.InfoLevel
+	case logrus.WarnLevel:
+		return logger.WarnLevel
+	case logrus.ErrorLevel:
+		return logger.ErrorLevel
+	default:
+		return logger.FatalLevel
+	}
+}
@@ -0,0 +1,160 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/server"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMicroLogger_Log(t *testing.T) {
+	tt := []struct {
+		name     string
+		level    logger.Level
+		expLevel string
+	}{
+		{
+			name:     "trace level is disabled",
+			level:    logger.TraceLevel,
+			expLevel: "",
+		},
+		{
+			name:     "debug level",
+			level:    logger.DebugLevel,
+			expLevel: "debug",
+		},
+		{
+			name:     "info level",
+			level:    logger.InfoLevel,
+			expLevel: "info",
+		},
+		{
+			name:     "warn level",
+			level:    logger.WarnLevel,
+			expLevel: "warning",
+		},
+		{
+			name:     "error level",
+			level:    logger.ErrorLevel,
+			expLevel: "error",
+		},
+		{
+			name:     "fatal level",
+			level:    logger.FatalLevel,
+			expLevel: "fatal",
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := newTestLogger(&buf)
+			l.SetLevel(logrus.DebugLevel)
+			m := NewMicroLogger(l)
+			err := m.Init(logger.WithFields(map[string]interface{}{"service": "user"}))
+			require.NoError(t, err)
+			m.Fields(map[string]interface{}{"id": "123"}).Logf(tc.level, "hello %s", "micro")
+			if tc.expLevel == "" {
+				require.Empty(t, buf.String())
+				return
+			}
+			var logMap map[string]interface{}
+			err = json.Unmarshal(buf.Bytes(), &logMap)
+			require.NoError(t, err)
+			require.Equal(t, tc.expLevel, logMap["level"])
+			require.Equal(t, "hello micro", logMap["msg"])
+			require.Equal(t, "user", logMap["service"])
+			require.Equal(t, "123", logMap["id"])
+		})
+	}
+}
+
+func TestMicroLogger_Options(t *testing.T) {
+	l := newTestLogger(&bytes.Buffer{})
+	m := NewMicroLogger(l)
+	require.Equal(t, logger.InfoLevel, m.Options().Level)
+	require.False(t, logger.V(logger.DebugLevel, m))
+	err := l.ChangeLevel("", logrus.DebugLevel, 0)
+	require.NoError(t, err)
+	require.Equal(t, logger.DebugLevel, m.Options().Level)
+	require.True(t, logger.V(logger.DebugLevel, m))
+	// go-micro level options must not affect the logger.
+	err = m.Init(logger.WithLevel(logger.ErrorLevel))
+	require.NoError(t, err)
+	require.Equal(t, logger.DebugLevel, m.Options().Level)
+	require.Equal(t, "logrus", m.String())
+}
+
+func TestLogger_HandlerWrapper(t *testing.T) {
+	tt := []struct {
+		name    string
+		handler server.HandlerFunc
+		expErr  error
+	}{
+		{
+			name: "handler error",
+			handler: func(ctx context.Context, req server.Request, rsp interface{}) error {
+				return errors.NotFound("user", "not found")
+			},
+			expErr: errors.NotFound("user", "not found"),
+		},
+		{
+			name: "panic",
+			handler: func(ctx context.Context, req server.Request, rsp interface{}) error {
+				panic("boom")
+			},
+			expErr: errors.InternalServerError("user", "panic: boom"),
+		},
+		{
+			name: "all ok",
+			handler: func(ctx context.Context, req server.Request, rsp interface{}) error {
+				require.Equal(t, "User.Get", FieldsFromContext(ctx)["endpoint"])
+				return nil
+			},
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			l := newTestLogger(buf)
+			fn := l.HandlerWrapper()(tc.handler)
+			err := fn(context.Background(), &testRequest{service: "user", endpoint: "User.Get"}, nil)
+			if tc.expErr != nil {
+				require.Error(t, err)
+				require.Equal(t, tc.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Empty(t, buf.String())
+		})
+	}
+}
+
+// testRequest implements the request methods used by the handler wrapper.
+type testRequest struct {
+	server.Request
+	service  string
+	endpoint string
+}
+
+func (r *testRequest) Service() string {
+	return r.service
+}
+
+func (r *testRequest) Endpoint() string {
+	return r.endpoint
+}
+
+func newTestLogger(buf *bytes.Buffer) *Logger {
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	return New(logger)
+}
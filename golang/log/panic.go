@@ -0,0 +1,116 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// There are the fields of the panic entries.
+const (
+	FieldPanic     = "panic"
+	FieldStack     = "stack"
+	FieldGoroutine = "goroutine"
+	FieldService   = "service"
+	FieldVersion   = "version"
+)
+
+type contextFieldsKey struct{}
+
+// PanicError represents recovered panic returned as an error.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error returns the panic error message.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// ContextWithFields returns a copy of the context with the fields added to the request fields.
+func ContextWithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	return context.WithValue(ctx, contextFieldsKey{}, mergeFields(FieldsFromContext(ctx), fields))
+}
+
+// FieldsFromContext returns the request fields of the context.
+func FieldsFromContext(ctx context.Context) logrus.Fields {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(contextFieldsKey{}).(logrus.Fields)
+	return fields
+}
+
+// FromContext returns a log entry with the request fields of the context.
+func (l *Logger) FromContext(ctx context.Context) *logrus.Entry {
+	return l.WithFields(FieldsFromContext(ctx))
+}
+
+// Go runs the function in a new goroutine.
+// A panic of the function is logged and flushed before re-panicking.
+func (l *Logger) Go(ctx context.Context, fn func(ctx context.Context)) {
+	go func() {
+		defer l.ReportCrash(ctx)
+		fn(ctx)
+	}()
+}
+
+// Recover recovers a panic and returns it as PanicError into err.
+// It must be deferred directly: defer l.Recover(ctx, &err).
+func (l *Logger) Recover(ctx context.Context, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	perr := &PanicError{Value: r, Stack: debug.Stack()}
+	l.logPanic(ctx, logrus.ErrorLevel, perr)
+	if err != nil {
+		*err = perr
+	}
+}
+
+// ReportCrash logs and flushes a panic of the current goroutine before re-panicking.
+// It's supposed to be deferred at the beginning of the main function: defer l.ReportCrash(ctx).
+func (l *Logger) ReportCrash(ctx context.Context) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	l.logPanic(ctx, logrus.FatalLevel, &PanicError{Value: r, Stack: debug.Stack()})
+	_ = l.Flush()
+	panic(r)
+}
+
+func (l *Logger) logPanic(ctx context.Context, level logrus.Level, perr *PanicError) {
+	fields := logrus.Fields{
+		FieldPanic:     fmt.Sprint(perr.Value),
+		FieldStack:     string(perr.Stack),
+		FieldGoroutine: goroutineID(perr.Stack),
+	}
+	if l.service != "" {
+		fields[FieldService] = l.service
+	}
+	if l.version != "" {
+		fields[FieldVersion] = l.version
+	}
+	l.WithFields(mergeFields(FieldsFromContext(ctx), fields)).Log(level, perr.Error())
+}
+
+// goroutineID parses the goroutine ID from the first line of the stack,
+// e.g. "goroutine 18 [running]:". Returns 0 if the ID could not be parsed.
+func goroutineID(stack []byte) uint64 {
+	stack = bytes.TrimPrefix(stack, []byte("goroutine "))
+	if i := bytes.IndexByte(stack, ' '); i > 0 {
+		stack = stack[:i]
+	}
+	id, err := strconv.ParseUint(string(stack), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
@@ -0,0 +1,108 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_Recover(t *testing.T) {
+	tt := []struct {
+		name   string
+		fn     func()
+		expErr string
+	}{
+		{
+			name: "no panic",
+			fn:   func() {},
+		},
+		{
+			name:   "panic",
+			fn:     func() { panic("boom") },
+			expErr: "panic: boom",
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			l := newTestLogger(buf)
+			l.service = "user"
+			l.version = "0.0.1"
+			ctx := ContextWithFields(context.Background(), logrus.Fields{"request_id": "42"})
+
+			err := func() (err error) {
+				defer l.Recover(ctx, &err)
+				tc.fn()
+				return nil
+			}()
+			if tc.expErr == "" {
+				require.NoError(t, err)
+				require.Empty(t, buf.String())
+				return
+			}
+			require.Error(t, err)
+			require.EqualError(t, err, tc.expErr)
+			perr, ok := err.(*PanicError)
+			require.True(t, ok)
+			require.Equal(t, "boom", perr.Value)
+
+			var entry map[string]interface{}
+			err = json.Unmarshal(buf.Bytes(), &entry)
+			require.NoError(t, err)
+			require.Equal(t, "error", entry["level"])
+			require.Equal(t, "panic: boom", entry["msg"])
+			require.Equal(t, "boom", entry[FieldPanic])
+			require.Equal(t, "42", entry["request_id"])
+			require.Equal(t, "user", entry[FieldService])
+			require.Equal(t, "0.0.1", entry[FieldVersion])
+			require.NotZero(t, entry[FieldGoroutine])
+			require.Contains(t, entry[FieldStack], "TestLogger_Recover")
+		})
+	}
+}
+
+func TestLogger_ReportCrash(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := newTestLogger(buf)
+	require.PanicsWithValue(t, "boom", func() {
+		defer l.ReportCrash(context.Background())
+		panic("boom")
+	})
+
+	var entry map[string]interface{}
+	err := json.Unmarshal(buf.Bytes(), &entry)
+	require.NoError(t, err)
+	require.Equal(t, "fatal", entry["level"])
+	require.Equal(t, "boom", entry[FieldPanic])
+	require.NotContains(t, entry, FieldService)
+}
+
+func Test_goroutineID(t *testing.T) {
+	tt := []struct {
+		name  string
+		stack string
+		exp   uint64
+	}{
+		{
+			name:  "all ok",
+			stack: "goroutine 18 [running]:\nmain.main()",
+			exp:   18,
+		},
+		{
+			name:  "invalid stack",
+			stack: "main.main()",
+			exp:   0,
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, goroutineID([]byte(tc.stack)))
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package log
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultRedactMask = "***"
+
+// maxRedactDepth limits the nesting of the processed values, e.g. for cyclic pointers.
+const maxRedactDepth = 32
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// There are default redaction value patterns.
+var (
+	// CreditCardPattern matches credit card numbers with optional separators.
+	// The Redactor masks the matches only if they pass the Luhn check,
+	// so the long numbers like timestamps and IDs are kept.
+	CreditCardPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	// JWTPattern matches JSON web tokens.
+	JWTPattern = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+)
+
+// urlUserinfoPattern matches userinfo part of the URL.
+var urlUserinfoPattern = regexp.MustCompile(`([A-Za-z][A-Za-z0-9+.-]*://)[^/?#@\s]+@`)
+
+// RedactConfig represents redaction rules configuration.
+type RedactConfig struct {
+	// Fields contains field names which values must be masked, case insensitive.
+	Fields []string
+	// Patterns contains value patterns which must be masked.
+	Patterns []*regexp.Regexp
+	// Mask replaces sensitive data, "***" by default.
+	Mask string
+	// KeepURLUserinfo disables masking of the userinfo part of URLs.
+	KeepURLUserinfo bool
+}
+
+// Redactor masks sensitive data in log entries.
+type Redactor struct {
+	fields   map[string]struct{}
+	patterns []*regexp.Regexp
+	mask     string
+	userinfo bool
+}
+
+// DefaultRedactConfig returns redaction rules for the common sensitive data.
+func DefaultRedactConfig() RedactConfig {
+	return RedactConfig{
+		Fields:   []string{"password", "token", "email", "secret", "authorization"},
+		Patterns: []*regexp.Regexp{CreditCardPattern, JWTPattern},
+	}
+}
+
+// NewRedactor creates new Redactor instance with the provided rules.
+func NewRedactor(cfg RedactConfig) *Redactor {
+	r := Redactor{
+		fields:   make(map[string]struct{}, len(cfg.Fields)),
+		patterns: cfg.Patterns,
+		mask:     cfg.Mask,
+		userinfo: !cfg.KeepURLUserinfo,
+	}
+	if r.mask == "" {
+		r.mask = defaultRedactMask
+	}
+	for i := range cfg.Fields {
+		r.fields[strings.ToLower(cfg.Fields[i])] = struct{}{}
+	}
+	return &r
+}
+
+// String masks sensitive data in the string value.
+func (r *Redactor) String(s string) string {
+	if r.userinfo {
+		s = urlUserinfoPattern.ReplaceAllString(s, "${1}"+r.mask+"@")
+	}
+	for i := range r.patterns {
+		if r.patterns[i] == CreditCardPattern {
+			s = r.patterns[i].ReplaceAllStringFunc(s, func(number string) string {
+				if luhn(number) {
+					return r.mask
+				}
+				return number
+			})
+			continue
+		}
+		s = r.patterns[i].ReplaceAllString(s, r.mask)
+	}
+	return s
+}
+
+// luhn checks the digits of the number by the Luhn algorithm, the separators are skipped.
+func luhn(number string) bool {
+	var (
+		sum    int
+		double bool
+	)
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// Fields returns a copy of the provided fields with masked sensitive data.
+// Nested maps, slices and structs are processed as well. Structs are replaced
+// by the maps of their exported fields named as the JSON encoding does.
+func (r *Redactor) Fields(fields logrus.Fields) logrus.Fields {
+	redacted := make(logrus.Fields, len(fields))
+	for k, v := range fields {
+		redacted[k] = r.field(k, v, 0)
+	}
+	return redacted
+}
+
+func (r *Redactor) field(key string, value interface{}, depth int) interface{} {
+	if _, ok := r.fields[strings.ToLower(key)]; ok && value != nil {
+		return r.mask
+	}
+	return r.value(value, depth)
+}
+
+func (r *Redactor) value(value interface{}, depth int) interface{} {
+	if depth > maxRedactDepth {
+		return r.mask
+	}
+	depth++
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return r.String(v)
+	case *structpb.Struct:
+		return r.protoStruct(v, depth)
+	case *structpb.Value:
+		return r.protoValue(v, depth)
+	case *structpb.ListValue:
+		return r.protoList(v, depth)
+	case error:
+		if s := v.Error(); r.String(s) != s {
+			return r.String(s)
+		}
+		return v
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		redacted := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			redacted[key] = r.field(key, iter.Value().Interface(), depth)
+		}
+		return redacted
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			// keep raw bytes as is.
+			return value
+		}
+		redacted := make([]interface{}, rv.Len())
+		for i := range redacted {
+			redacted[i] = r.value(rv.Index(i).Interface(), depth)
+		}
+		return redacted
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return value
+		}
+		switch elem := rv.Elem(); elem.Kind() {
+		case reflect.Map, reflect.Slice:
+			return r.value(elem.Interface(), depth)
+		case reflect.Struct:
+			if !hasOwnEncoding(rv.Type()) {
+				return r.structFields(elem, depth)
+			}
+		}
+	case reflect.Struct:
+		if !hasOwnEncoding(rv.Type()) {
+			return r.structFields(rv, depth)
+		}
+	}
+
+	if v, ok := value.(fmt.Stringer); ok {
+		if s := v.String(); r.String(s) != s {
+			return r.String(s)
+		}
+	}
+	return value
+}
+
+// structFields returns the map of the exported struct fields named by their JSON tags.
+// The fields omitted by the JSON encoding are skipped.
+func (r *Redactor) structFields(rv reflect.Value, depth int) map[string]interface{} {
+	rt := rv.Type()
+	redacted := make(map[string]interface{}, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name, opts := f.Name, ""
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			if j := strings.Index(tag, ","); j >= 0 {
+				tag, opts = tag[:j], tag[j:]
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fv := rv.Field(i)
+		if strings.Contains(opts, ",omitempty") && fv.IsZero() {
+			continue
+		}
+		redacted[name] = r.field(name, fv.Interface(), depth)
+	}
+	return redacted
+}
+
+func (r *Redactor) protoStruct(s *structpb.Struct, depth int) interface{} {
+	if s == nil {
+		return nil
+	}
+	redacted := make(map[string]interface{}, len(s.Fields))
+	for k, v := range s.Fields {
+		redacted[k] = r.field(k, v, depth)
+	}
+	return redacted
+}
+
+func (r *Redactor) protoList(l *structpb.ListValue, depth int) interface{} {
+	if l == nil {
+		return nil
+	}
+	redacted := make([]interface{}, len(l.Values))
+	for i := range l.Values {
+		redacted[i] = r.protoValue(l.Values[i], depth)
+	}
+	return redacted
+}
+
+// protoValue returns the plain value of the protobuf value with masked sensitive data.
+func (r *Redactor) protoValue(v *structpb.Value, depth int) interface{} {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return r.String(kind.StringValue)
+	case *structpb.Value_NumberValue:
+		return kind.NumberValue
+	case *structpb.Value_BoolValue:
+		return kind.BoolValue
+	case *structpb.Value_StructValue:
+		return r.protoStruct(kind.StructValue, depth)
+	case *structpb.Value_ListValue:
+		return r.protoList(kind.ListValue, depth)
+	default:
+		return nil
+	}
+}
+
+// hasOwnEncoding checks if the type or its pointer defines its own JSON or text encoding, e.g. time.Time.
+func hasOwnEncoding(t reflect.Type) bool {
+	if t.Kind() != reflect.Ptr {
+		t = reflect.PtrTo(t)
+	}
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType)
+}
+
+// RedactFormatter masks sensitive data of the entries
+// before passing them to the wrapped formatter.
+type RedactFormatter struct {
+	Formatter logrus.Formatter
+	Redactor  *Redactor
+}
+
+// Format formats the entry with masked message and fields.
+func (f *RedactFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	redacted := *entry
+	redacted.Message = f.Redactor.String(entry.Message)
+	redacted.Data = f.Redactor.Fields(entry.Data)
+	return f.Formatter.Format(&redacted)
+}
+
+// Redact enables masking of sensitive data for the root logger and all the sub-loggers.
+// The current formatters are wrapped, so masking applies whatever formatter is used.
+func (l *Logger) Redact(r *Redactor) {
+	l.levels.each(func(logger *logrus.Logger) {
+		logger.SetFormatter(&RedactFormatter{
+			Formatter: logger.Formatter,
+			Redactor:  r,
+		})
+	})
+}
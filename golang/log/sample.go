@@ -0,0 +1,220 @@
+package log
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSamplingInterval = time.Second
+	defaultSummaryInterval  = time.Minute
+
+	// summaryField marks the summary entries, which must not be sampled.
+	summaryField = "sampling_suppressed"
+)
+
+// SamplingRule represents sampling rule for a level.
+type SamplingRule struct {
+	// First is the number of messages with the same key logged every interval.
+	First int
+	// Thereafter makes every Mth message after the first ones logged.
+	// Zero suppresses all of them.
+	Thereafter int
+}
+
+// SamplingConfig represents Sampler configuration.
+type SamplingConfig struct {
+	// Interval is the sampling window, 1s by default.
+	Interval time.Duration
+	// SummaryInterval is the period of the summary emitting, 1m by default.
+	SummaryInterval time.Duration
+	// Rules contains sampling rules by levels, levels without a rule are not sampled.
+	Rules map[logrus.Level]SamplingRule
+	// Key returns the key of the entry, the level and the message by default.
+	Key func(entry *logrus.Entry) string
+}
+
+// Sampler represents log entries sampler.
+type Sampler struct {
+	cfg        SamplingConfig
+	mu         sync.Mutex
+	counters   map[string]*sampleCounter
+	suppressed map[string]*sampleSummary
+	now        func() time.Time
+	stop       chan struct{}
+	stopped    chan struct{}
+}
+
+type sampleCounter struct {
+	start time.Time
+	count int
+}
+
+type sampleSummary struct {
+	level   logrus.Level
+	message string
+	count   uint64
+}
+
+// NewSampler creates new Sampler instance.
+func NewSampler(cfg SamplingConfig) *Sampler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultSamplingInterval
+	}
+	if cfg.SummaryInterval <= 0 {
+		cfg.SummaryInterval = defaultSummaryInterval
+	}
+	if cfg.Key == nil {
+		cfg.Key = func(entry *logrus.Entry) string {
+			return entry.Level.String() + ":" + entry.Message
+		}
+	}
+	return &Sampler{
+		cfg:        cfg,
+		counters:   make(map[string]*sampleCounter),
+		suppressed: make(map[string]*sampleSummary),
+		now:        time.Now,
+	}
+}
+
+// Sample returns true if the entry must be logged.
+func (s *Sampler) Sample(entry *logrus.Entry) bool {
+	rule, ok := s.cfg.Rules[entry.Level]
+	if !ok {
+		return true
+	}
+	if _, ok := entry.Data[summaryField]; ok {
+		return true
+	}
+
+	key := s.cfg.Key(entry)
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counter, ok := s.counters[key]
+	if !ok || now.Sub(counter.start) >= s.cfg.Interval {
+		counter = &sampleCounter{start: now}
+		s.counters[key] = counter
+	}
+	counter.count++
+
+	if counter.count <= rule.First {
+		return true
+	}
+	if rule.Thereafter > 0 && (counter.count-rule.First)%rule.Thereafter == 0 {
+		return true
+	}
+
+	summary, ok := s.suppressed[key]
+	if !ok {
+		summary = &sampleSummary{
+			level:   entry.Level,
+			message: entry.Message,
+		}
+		s.suppressed[key] = summary
+	}
+	summary.count++
+	return false
+}
+
+// summaries returns suppressed messages since the last call and removes expired counters.
+func (s *Sampler) summaries() []sampleSummary {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, counter := range s.counters {
+		if now.Sub(counter.start) >= s.cfg.Interval {
+			delete(s.counters, key)
+		}
+	}
+
+	keys := make([]string, 0, len(s.suppressed))
+	for key := range s.suppressed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	summaries := make([]sampleSummary, 0, len(keys))
+	for _, key := range keys {
+		summaries = append(summaries, *s.suppressed[key])
+	}
+	s.suppressed = make(map[string]*sampleSummary)
+	return summaries
+}
+
+// Stop stops the summary emitting and emits the last summary.
+func (s *Sampler) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.stopped
+	s.stop = nil
+}
+
+func (s *Sampler) run(logger *Logger) {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.cfg.SummaryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.emit(logger)
+		case <-s.stop:
+			s.emit(logger)
+			return
+		}
+	}
+}
+
+func (s *Sampler) emit(logger *Logger) {
+	for _, summary := range s.summaries() {
+		logger.WithFields(logrus.Fields{
+			summaryField:       summary.count,
+			"sampling_message": summary.message,
+		}).Logf(summary.level, "suppressed %d similar messages", summary.count)
+	}
+}
+
+// SamplingFormatter drops the entries rejected by the sampler
+// before passing them to the wrapped formatter.
+type SamplingFormatter struct {
+	Formatter logrus.Formatter
+	Sampler   *Sampler
+}
+
+// Format formats the entry if it's sampled, otherwise returns no data.
+func (f *SamplingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if !f.Sampler.Sample(entry) {
+		return nil, nil
+	}
+	return f.Formatter.Format(entry)
+}
+
+// Sample enables sampling for the root logger and all the sub-loggers.
+// The summary of the suppressed messages is logged periodically by the logger
+// until the returned Sampler is stopped.
+func (l *Logger) Sample(cfg SamplingConfig) *Sampler {
+	s := NewSampler(cfg)
+	l.levels.each(func(logger *logrus.Logger) {
+		logger.SetFormatter(&SamplingFormatter{
+			Formatter: logger.Formatter,
+			Sampler:   s,
+		})
+	})
+
+	s.stop = make(chan struct{})
+	s.stopped = make(chan struct{})
+	go s.run(l)
+
+	return s
+}
@@ -0,0 +1,112 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampler_Sample(t *testing.T) {
+	tt := []struct {
+		name  string
+		level logrus.Level
+		msgs  int
+		exp   int
+	}{
+		{
+			name:  "level without rule",
+			level: logrus.InfoLevel,
+			msgs:  10,
+			exp:   10,
+		},
+		{
+			name:  "first and thereafter",
+			level: logrus.ErrorLevel,
+			msgs:  10,
+			exp:   4,
+		},
+		{
+			name:  "first only",
+			level: logrus.DebugLevel,
+			msgs:  10,
+			exp:   1,
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewSampler(SamplingConfig{
+				Interval: time.Minute,
+				Rules: map[logrus.Level]SamplingRule{
+					logrus.ErrorLevel: {First: 2, Thereafter: 3},
+					logrus.DebugLevel: {First: 1},
+				},
+			})
+			var sampled int
+			for i := 0; i < tc.msgs; i++ {
+				if s.Sample(&logrus.Entry{Level: tc.level, Message: "hello"}) {
+					sampled++
+				}
+			}
+			require.Equal(t, tc.exp, sampled)
+		})
+	}
+
+	t.Run("new interval", func(t *testing.T) {
+		now := time.Now()
+		s := NewSampler(SamplingConfig{
+			Interval: time.Second,
+			Rules: map[logrus.Level]SamplingRule{
+				logrus.ErrorLevel: {First: 1},
+			},
+		})
+		s.now = func() time.Time { return now }
+		entry := logrus.Entry{Level: logrus.ErrorLevel, Message: "hello"}
+		require.True(t, s.Sample(&entry))
+		require.False(t, s.Sample(&entry))
+		require.True(t, s.Sample(&logrus.Entry{Level: logrus.ErrorLevel, Message: "another"}))
+		now = now.Add(time.Second)
+		require.True(t, s.Sample(&entry))
+		require.Equal(t, []sampleSummary{
+			{level: logrus.ErrorLevel, message: "hello", count: 1},
+		}, s.summaries())
+		require.Empty(t, s.summaries())
+	})
+}
+
+func TestLogger_Sample(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	s := l.Sample(SamplingConfig{
+		Interval:        time.Minute,
+		SummaryInterval: time.Hour,
+		Rules: map[logrus.Level]SamplingRule{
+			logrus.ErrorLevel: {First: 2},
+		},
+	})
+	child := l.Named("storage")
+	for i := 0; i < 5; i++ {
+		l.Error("connection refused")
+		child.Error("connection refused")
+	}
+	l.Info("done")
+	s.Stop()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Equal(t, 4, len(lines))
+	var logMap map[string]interface{}
+	err := json.Unmarshal([]byte(lines[2]), &logMap)
+	require.NoError(t, err)
+	require.Equal(t, "done", logMap["msg"])
+	err = json.Unmarshal([]byte(lines[3]), &logMap)
+	require.NoError(t, err)
+	require.Equal(t, "error", logMap["level"])
+	require.Equal(t, "suppressed 8 similar messages", logMap["msg"])
+	require.Equal(t, "connection refused", logMap["sampling_message"])
+	require.Equal(t, float64(8), logMap[summaryField])
+}
@@ -0,0 +1,546 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// There are supported HTTP sink formats.
+const (
+	SinkLoki          = "loki"
+	SinkElasticsearch = "elasticsearch"
+)
+
+const (
+	defaultSinkBatchSize     = 100
+	defaultSinkBatchInterval = time.Second
+	defaultSinkBufferSize    = 10000
+	defaultSinkMaxRetries    = 3
+	defaultSinkBackoff       = 100 * time.Millisecond
+	defaultSinkTimeout       = 10 * time.Second
+	defaultSinkSpillMaxSize  = 100 << 20
+	defaultSinkCloseTimeout  = 10 * time.Second
+)
+
+// HTTPSinkConfig represents HTTPSink configuration.
+type HTTPSinkConfig struct {
+	// URL is the ingestion endpoint, e.g. Loki push or Elasticsearch bulk API.
+	URL string
+	// Format is the payload format: loki or elasticsearch.
+	Format string
+	// Labels are the Loki stream labels.
+	Labels map[string]string
+	// Index is the Elasticsearch index.
+	Index string
+	// Headers are added to every request, e.g. authorization.
+	Headers map[string]string
+	// BatchSize is the max number of the lines in a batch, 100 by default.
+	BatchSize int
+	// BatchInterval is the max time a line waits for the batch sending, 1s by default.
+	BatchInterval time.Duration
+	// BufferSize is the max number of the lines waiting for the batching, 10000 by default.
+	// The lines are dropped when the buffer is full.
+	BufferSize int
+	// MaxRetries is the number of the retries of a failed batch, 3 by default.
+	MaxRetries int
+	// Backoff is the initial retry backoff, doubled on every retry, 100ms by default.
+	Backoff time.Duration
+	// SpillPath is the file the failed batches are spilled into.
+	// The spilled lines are sent again after the next successful batch with their original time.
+	SpillPath string
+	// SpillMaxSize is the max size of the spill file in bytes, 100MB by default.
+	// The lines of the failed batches are dropped when the spill file is full.
+	SpillMaxSize int64
+	// Client is the HTTP client, the client with 10s timeout by default.
+	Client *http.Client
+	// CloseTimeout is the max time Close waits for the buffered lines sending, 10s by default.
+	CloseTimeout time.Duration
+}
+
+// HTTPSink represents log sink which sends the log lines to the HTTP ingestion
+// endpoint in compressed batches. It's supposed to be added to the logger by
+// Logger.AddSink and closed on shutdown to send the buffered lines.
+type HTTPSink struct {
+	cfg     HTTPSinkConfig
+	queue   chan sinkLine
+	flushes chan chan error
+	done    chan struct{}
+	stopped chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+	dropped uint64
+	spillMu sync.Mutex
+	// closeErr is the error of the sending on closing.
+	closeErr error
+	// ctx cancels the requests when the closing deadline is exceeded.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type sinkLine struct {
+	time time.Time
+	data []byte
+}
+
+// NewHTTPSink creates new HTTPSink instance and starts its batching.
+func NewHTTPSink(cfg HTTPSinkConfig) (*HTTPSink, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("sink url is required")
+	}
+	if cfg.Format != SinkLoki && cfg.Format != SinkElasticsearch {
+		return nil, errors.Errorf("unknown sink format %s", cfg.Format)
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultSinkBatchSize
+	}
+	if cfg.BatchInterval <= 0 {
+		cfg.BatchInterval = defaultSinkBatchInterval
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultSinkBufferSize
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = defaultSinkMaxRetries
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = defaultSinkBackoff
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: defaultSinkTimeout}
+	}
+	if cfg.SpillMaxSize <= 0 {
+		cfg.SpillMaxSize = defaultSinkSpillMaxSize
+	}
+	if cfg.CloseTimeout <= 0 {
+		cfg.CloseTimeout = defaultSinkCloseTimeout
+	}
+
+	s := HTTPSink{
+		cfg:     cfg,
+		queue:   make(chan sinkLine, cfg.BufferSize),
+		flushes: make(chan chan error),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	go s.run()
+
+	return &s, nil
+}
+
+// Write puts a copy of the log line into the buffer.
+func (s *HTTPSink) Write(p []byte) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return 0, ErrWriterClosed
+	}
+	data := bytes.TrimRight(p, "\n")
+	if len(data) == 0 {
+		return len(p), nil
+	}
+
+	line := sinkLine{
+		time: time.Now(),
+		data: make([]byte, len(data)),
+	}
+	copy(line.data, data)
+
+	select {
+	case s.queue <- line:
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of the lines dropped due to the buffer overflow.
+func (s *HTTPSink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Flush sends all the buffered lines.
+func (s *HTTPSink) Flush() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return ErrWriterClosed
+	}
+
+	res := make(chan error)
+	s.flushes <- res
+	return <-res
+}
+
+// Close sends all the buffered lines within the close timeout and stops the sink, see Shutdown.
+func (s *HTTPSink) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.CloseTimeout)
+	defer cancel()
+	return s.Shutdown(ctx)
+}
+
+// Shutdown sends all the buffered lines and stops the sink. The failed batches
+// are not retried and the lines after the first failed batch are not sent,
+// they are spilled if the spill file is set. The sending is canceled when the context is done.
+// The error of the sending is returned.
+func (s *HTTPSink) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrWriterClosed
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.done)
+	select {
+	case <-s.stopped:
+	case <-ctx.Done():
+		s.cancel()
+		<-s.stopped
+	}
+	s.cancel()
+	return s.closeErr
+}
+
+func (s *HTTPSink) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.cfg.BatchInterval)
+	defer ticker.Stop()
+
+	batch := make([]sinkLine, 0, s.cfg.BatchSize)
+	for {
+		select {
+		case line := <-s.queue:
+			batch = append(batch, line)
+			if len(batch) >= s.cfg.BatchSize {
+				_ = s.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) != 0 {
+				_ = s.send(batch)
+				batch = batch[:0]
+			}
+		case res := <-s.flushes:
+			res <- s.drain(batch)
+			batch = batch[:0]
+		case <-s.done:
+			s.closeErr = s.drain(batch)
+			return
+		}
+	}
+}
+
+// drain sends the batch and all the queued lines. After the first failed batch
+// the rest of the lines are spilled without sending, so the draining doesn't wait
+// for the failing endpoint.
+func (s *HTTPSink) drain(batch []sinkLine) error {
+	var err error
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err == nil {
+			err = s.send(batch)
+		} else if spillErr := s.spill(batch); spillErr != nil {
+			err = errors.Wrapf(spillErr, "%v", err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case line := <-s.queue:
+			batch = append(batch, line)
+			if len(batch) >= s.cfg.BatchSize {
+				flush()
+			}
+		default:
+			flush()
+			return err
+		}
+	}
+}
+
+// send sends the batch with retries, spills it on failure
+// and sends the spilled lines on success.
+func (s *HTTPSink) send(batch []sinkLine) error {
+	if err := s.sendWithRetries(batch); err != nil {
+		if spillErr := s.spill(batch); spillErr != nil {
+			return errors.Wrapf(spillErr, "%v", err)
+		}
+		return err
+	}
+	return s.replaySpill()
+}
+
+func (s *HTTPSink) sendWithRetries(batch []sinkLine) error {
+	payload, contentType, err := s.encode(batch)
+	if err != nil {
+		return err
+	}
+
+	backoff := s.cfg.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(payload, contentType)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= s.cfg.MaxRetries {
+			return err
+		}
+		// the closing sink doesn't retry.
+		select {
+		case <-time.After(backoff):
+		case <-s.done:
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// post sends the payload and returns whether the failed request could be retried.
+func (s *HTTPSink) post(payload []byte, contentType string) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, s.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return false, errors.Wrap(err, "could not create sink request")
+	}
+	req = req.WithContext(s.ctx)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Content-Encoding", "gzip")
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return true, errors.Wrap(err, "could not send log batch")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+	return retry, errors.Errorf("could not send log batch: unexpected status %d", resp.StatusCode)
+}
+
+// encode returns gzipped payload of the batch and its content type.
+func (s *HTTPSink) encode(batch []sinkLine) ([]byte, string, error) {
+	var (
+		buf         bytes.Buffer
+		contentType string
+	)
+	zw := gzip.NewWriter(&buf)
+
+	switch s.cfg.Format {
+	case SinkLoki:
+		contentType = "application/json"
+		values := make([][2]string, len(batch))
+		for i := range batch {
+			values[i] = [2]string{strconv.FormatInt(batch[i].time.UnixNano(), 10), string(batch[i].data)}
+		}
+		push := lokiPush{
+			Streams: []lokiStream{
+				{
+					Stream: s.cfg.Labels,
+					Values: values,
+				},
+			},
+		}
+		if err := json.NewEncoder(zw).Encode(push); err != nil {
+			return nil, "", errors.Wrap(err, "could not encode log batch")
+		}
+	default:
+		contentType = "application/x-ndjson"
+		action := []byte("{\"index\":{}}\n")
+		if s.cfg.Index != "" {
+			action = []byte(fmt.Sprintf("{\"index\":{\"_index\":%q}}\n", s.cfg.Index))
+		}
+		for i := range batch {
+			_, _ = zw.Write(action)
+			_, _ = zw.Write(batch[i].data)
+			_, _ = zw.Write([]byte{'\n'})
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, "", errors.Wrap(err, "could not compress log batch")
+	}
+	return buf.Bytes(), contentType, nil
+}
+
+// spill appends the batch lines with their time into the spill file.
+// The lines which don't fit into the max size of the spill file are dropped.
+func (s *HTTPSink) spill(batch []sinkLine) error {
+	if s.cfg.SpillPath == "" {
+		return nil
+	}
+
+	s.spillMu.Lock()
+	defer s.spillMu.Unlock()
+
+	f, err := os.OpenFile(s.cfg.SpillPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "could not open spill file")
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return errors.Wrap(err, "could not stat spill file")
+	}
+
+	size := info.Size()
+	w := bufio.NewWriter(f)
+	for i := range batch {
+		line := encodeSpillLine(batch[i])
+		if size+int64(len(line)) > s.cfg.SpillMaxSize {
+			atomic.AddUint64(&s.dropped, uint64(len(batch)-i))
+			break
+		}
+		_, _ = w.Write(line)
+		size += int64(len(line))
+	}
+	if err := w.Flush(); err != nil {
+		return errors.Wrap(err, "could not spill log batch")
+	}
+	return nil
+}
+
+// replaySpill sends the spilled lines and truncates the spill file on success.
+func (s *HTTPSink) replaySpill() error {
+	if s.cfg.SpillPath == "" {
+		return nil
+	}
+
+	s.spillMu.Lock()
+	defer s.spillMu.Unlock()
+
+	data, err := ioutil.ReadFile(s.cfg.SpillPath)
+	if err != nil || len(data) == 0 {
+		// there is nothing to replay.
+		return nil
+	}
+
+	lines := bytes.Split(bytes.TrimRight(data, "\n"), []byte{'\n'})
+	for len(lines) != 0 {
+		n := s.cfg.BatchSize
+		if n > len(lines) {
+			n = len(lines)
+		}
+		batch := make([]sinkLine, n)
+		for i := range batch {
+			batch[i] = decodeSpillLine(lines[i])
+		}
+		if err := s.sendWithRetries(batch); err != nil {
+			// keep the lines which are not sent yet.
+			if wErr := ioutil.WriteFile(s.cfg.SpillPath, append(bytes.Join(lines, []byte{'\n'}), '\n'), 0644); wErr != nil {
+				return errors.Wrapf(wErr, "%v", err)
+			}
+			return err
+		}
+		lines = lines[n:]
+	}
+
+	return os.Truncate(s.cfg.SpillPath, 0)
+}
+
+// encodeSpillLine returns the spill file line: the line time in unix nanoseconds,
+// the tab and the line data.
+func encodeSpillLine(line sinkLine) []byte {
+	b := make([]byte, 0, len(line.data)+21)
+	b = strconv.AppendInt(b, line.time.UnixNano(), 10)
+	b = append(b, '\t')
+	b = append(b, line.data...)
+	return append(b, '\n')
+}
+
+// decodeSpillLine parses the spill file line. The line without the time gets the current time.
+func decodeSpillLine(data []byte) sinkLine {
+	if i := bytes.IndexByte(data, '\t'); i > 0 {
+		if ns, err := strconv.ParseInt(string(data[:i]), 10, 64); err == nil {
+			return sinkLine{time: time.Unix(0, ns), data: data[i+1:]}
+		}
+	}
+	return sinkLine{time: time.Now(), data: data}
+}
+
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// multiWriter writes into all the writers and forwards Flush and Close calls.
+type multiWriter struct {
+	writers []io.Writer
+}
+
+func (w *multiWriter) Write(p []byte) (int, error) {
+	var err error
+	for i := range w.writers {
+		if _, wErr := w.writers[i].Write(p); wErr != nil && err == nil {
+			err = wErr
+		}
+	}
+	return len(p), err
+}
+
+func (w *multiWriter) Flush() error {
+	var err error
+	for i := range w.writers {
+		if f, ok := w.writers[i].(interface{ Flush() error }); ok {
+			if fErr := f.Flush(); fErr != nil && err == nil {
+				err = fErr
+			}
+		}
+	}
+	return err
+}
+
+func (w *multiWriter) Close() error {
+	var err error
+	for i := range w.writers {
+		if c, ok := w.writers[i].(io.Closer); ok {
+			if cErr := c.Close(); cErr != nil && err == nil {
+				err = cErr
+			}
+		}
+	}
+	return err
+}
+
+// AddSink makes the root logger and all the sub-loggers write into the sink as well.
+// The sink receives the formatted lines, so redaction and sampling apply to it.
+// Logger.Flush and Logger.Close are forwarded to the sink.
+func (l *Logger) AddSink(sink io.Writer) {
+	l.levels.out.update(func(out io.Writer) io.Writer {
+		if mw, ok := out.(*multiWriter); ok {
+			return &multiWriter{writers: append(mw.writers[:len(mw.writers):len(mw.writers)], sink)}
+		}
+		return &multiWriter{writers: []io.Writer{out, sink}}
+	})
+}
@@ -0,0 +1,356 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewHTTPSink(t *testing.T) {
+	tt := []struct {
+		name   string
+		cfg    HTTPSinkConfig
+		expErr string
+	}{
+		{
+			name:   "url is required error",
+			cfg:    HTTPSinkConfig{Format: SinkLoki},
+			expErr: "sink url is required",
+		},
+		{
+			name:   "unknown format error",
+			cfg:    HTTPSinkConfig{URL: "http://localhost", Format: "syslog"},
+			expErr: "unknown sink format syslog",
+		},
+		{
+			name: "all ok",
+			cfg:  HTTPSinkConfig{URL: "http://localhost", Format: SinkElasticsearch},
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewHTTPSink(tc.cfg)
+			if tc.expErr != "" {
+				require.Error(t, err)
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, s.Close())
+		})
+	}
+}
+
+func TestHTTPSink_Loki(t *testing.T) {
+	srv := newTestIngestServer(t, 0)
+	defer srv.Close()
+
+	s, err := NewHTTPSink(HTTPSinkConfig{
+		URL:           srv.URL,
+		Format:        SinkLoki,
+		Labels:        map[string]string{"service": "user"},
+		Headers:       map[string]string{"X-Scope-OrgID": "open-q"},
+		BatchSize:     2,
+		BatchInterval: time.Hour,
+	})
+	require.NoError(t, err)
+	l := newTestLogger(&bytes.Buffer{})
+	l.AddSink(s)
+	for i := 0; i < 3; i++ {
+		l.Infof("hello %d", i)
+	}
+	err = l.Close()
+	require.NoError(t, err)
+
+	bodies := srv.bodies()
+	require.Equal(t, 2, len(bodies))
+	var push lokiPush
+	err = json.Unmarshal(bodies[0], &push)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(push.Streams))
+	require.Equal(t, map[string]string{"service": "user"}, push.Streams[0].Stream)
+	require.Equal(t, 2, len(push.Streams[0].Values))
+	require.Contains(t, push.Streams[0].Values[0][1], `"msg":"hello 0"`)
+	require.Equal(t, "open-q", srv.header("X-Scope-OrgID"))
+}
+
+func TestHTTPSink_Elasticsearch(t *testing.T) {
+	srv := newTestIngestServer(t, 0)
+	defer srv.Close()
+
+	s, err := NewHTTPSink(HTTPSinkConfig{
+		URL:           srv.URL,
+		Format:        SinkElasticsearch,
+		Index:         "logs",
+		BatchInterval: 5 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer s.Close()
+	_, err = s.Write([]byte("{\"msg\":\"hello\"}\n"))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(srv.bodies()) == 1
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, "{\"index\":{\"_index\":\"logs\"}}\n{\"msg\":\"hello\"}\n", string(srv.bodies()[0]))
+	require.Equal(t, "application/x-ndjson", srv.header("Content-Type"))
+}
+
+func TestHTTPSink_Retry(t *testing.T) {
+	srv := newTestIngestServer(t, 2)
+	defer srv.Close()
+
+	s, err := NewHTTPSink(HTTPSinkConfig{
+		URL:           srv.URL,
+		Format:        SinkElasticsearch,
+		BatchInterval: time.Hour,
+		Backoff:       time.Millisecond,
+	})
+	require.NoError(t, err)
+	_, err = s.Write([]byte("{\"msg\":\"hello\"}\n"))
+	require.NoError(t, err)
+	err = s.Flush()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(srv.bodies()))
+	require.Equal(t, 3, srv.requests())
+	require.NoError(t, s.Close())
+}
+
+func TestHTTPSink_Spill(t *testing.T) {
+	spillPath := path.Join(os.TempDir(), "sink.spill")
+	defer os.Remove(spillPath)
+	srv := newTestIngestServer(t, 2)
+	defer srv.Close()
+
+	s, err := NewHTTPSink(HTTPSinkConfig{
+		URL:           srv.URL,
+		Format:        SinkElasticsearch,
+		BatchInterval: time.Hour,
+		MaxRetries:    -1,
+		SpillPath:     spillPath,
+	})
+	require.NoError(t, err)
+
+	// the endpoint is down, so the line is spilled.
+	_, err = s.Write([]byte("{\"msg\":\"first\"}\n"))
+	require.NoError(t, err)
+	err = s.Flush()
+	require.Error(t, err)
+	require.EqualError(t, err, "could not send log batch: unexpected status 503")
+	data, err := ioutil.ReadFile(spillPath)
+	require.NoError(t, err)
+	require.Regexp(t, "^[0-9]+\t\\{\"msg\":\"first\"\\}\n$", string(data))
+
+	// the endpoint is still down.
+	_, err = s.Write([]byte("{\"msg\":\"second\"}\n"))
+	require.NoError(t, err)
+	err = s.Flush()
+	require.Error(t, err)
+
+	// the endpoint is up, so the spilled lines are sent after the batch.
+	_, err = s.Write([]byte("{\"msg\":\"third\"}\n"))
+	require.NoError(t, err)
+	err = s.Close()
+	require.NoError(t, err)
+	bodies := srv.bodies()
+	require.Equal(t, 2, len(bodies))
+	require.Equal(t, "{\"index\":{}}\n{\"msg\":\"third\"}\n", string(bodies[0]))
+	require.Equal(t, "{\"index\":{}}\n{\"msg\":\"first\"}\n{\"index\":{}}\n{\"msg\":\"second\"}\n", string(bodies[1]))
+	data, err = ioutil.ReadFile(spillPath)
+	require.NoError(t, err)
+	require.Empty(t, data)
+}
+
+func TestHTTPSink_SpillTime(t *testing.T) {
+	spillPath := path.Join(os.TempDir(), "sink.spill")
+	defer os.Remove(spillPath)
+	srv := newTestIngestServer(t, 1)
+	defer srv.Close()
+
+	s, err := NewHTTPSink(HTTPSinkConfig{
+		URL:           srv.URL,
+		Format:        SinkLoki,
+		BatchInterval: time.Hour,
+		MaxRetries:    -1,
+		SpillPath:     spillPath,
+	})
+	require.NoError(t, err)
+
+	_, err = s.Write([]byte("{\"msg\":\"first\"}\n"))
+	require.NoError(t, err)
+	written := time.Now()
+	require.Error(t, s.Flush())
+	time.Sleep(10 * time.Millisecond)
+
+	_, err = s.Write([]byte("{\"msg\":\"second\"}\n"))
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	bodies := srv.bodies()
+	require.Equal(t, 2, len(bodies))
+	var push lokiPush
+	err = json.Unmarshal(bodies[1], &push)
+	require.NoError(t, err)
+	require.Equal(t, `{"msg":"first"}`, push.Streams[0].Values[0][1])
+	ns, err := strconv.ParseInt(push.Streams[0].Values[0][0], 10, 64)
+	require.NoError(t, err)
+	// the spilled line keeps the time of its writing.
+	require.False(t, time.Unix(0, ns).After(written))
+}
+
+func TestHTTPSink_SpillMaxSize(t *testing.T) {
+	spillPath := path.Join(os.TempDir(), "sink.spill")
+	defer os.Remove(spillPath)
+	srv := newTestIngestServer(t, 10)
+	defer srv.Close()
+
+	s, err := NewHTTPSink(HTTPSinkConfig{
+		URL:           srv.URL,
+		Format:        SinkElasticsearch,
+		BatchInterval: time.Hour,
+		MaxRetries:    -1,
+		SpillPath:     spillPath,
+		SpillMaxSize:  50,
+	})
+	require.NoError(t, err)
+	for _, msg := range []string{"first", "second", "third"} {
+		_, err = s.Write([]byte("{\"msg\":\"" + msg + "\"}\n"))
+		require.NoError(t, err)
+	}
+	// the endpoint is down on closing, so the error is returned.
+	err = s.Close()
+	require.Error(t, err)
+	require.EqualError(t, err, "could not send log batch: unexpected status 503")
+
+	data, err := ioutil.ReadFile(spillPath)
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(data), 50)
+	require.Equal(t, 1, strings.Count(string(data), "\n"))
+	require.Equal(t, uint64(2), s.Dropped())
+}
+
+func TestHTTPSink_CloseFailing(t *testing.T) {
+	spillPath := path.Join(os.TempDir(), "sink.spill")
+	defer os.Remove(spillPath)
+	srv := newTestIngestServer(t, 1000)
+	defer srv.Close()
+
+	s, err := NewHTTPSink(HTTPSinkConfig{
+		URL:           srv.URL,
+		Format:        SinkElasticsearch,
+		BatchSize:     10,
+		BatchInterval: time.Hour,
+		Backoff:       time.Second,
+		SpillPath:     spillPath,
+	})
+	require.NoError(t, err)
+	for i := 0; i < 200; i++ {
+		_, err = s.Write([]byte("{\"msg\":\"hello\"}\n"))
+		require.NoError(t, err)
+	}
+
+	// the failed batch is not retried and the rest of the lines are spilled without sending.
+	start := time.Now()
+	err = s.Close()
+	require.EqualError(t, err, "could not send log batch: unexpected status 503")
+	require.Less(t, int64(time.Since(start)), int64(time.Second))
+	require.Equal(t, 1, srv.requests())
+	data, err := ioutil.ReadFile(spillPath)
+	require.NoError(t, err)
+	require.Equal(t, 200, strings.Count(string(data), "\n"))
+}
+
+func TestHTTPSink_Shutdown(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s, err := NewHTTPSink(HTTPSinkConfig{
+		URL:           srv.URL,
+		Format:        SinkElasticsearch,
+		BatchInterval: time.Hour,
+	})
+	require.NoError(t, err)
+	_, err = s.Write([]byte("{\"msg\":\"hello\"}\n"))
+	require.NoError(t, err)
+
+	// the hanging request is canceled when the context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = s.Shutdown(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "could not send log batch")
+	require.Less(t, int64(time.Since(start)), int64(time.Second))
+	require.Equal(t, ErrWriterClosed, s.Close())
+}
+
+// testIngestServer records gzipped request bodies
+// and fails the first requests with 503 status.
+type testIngestServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	failures int
+	count    int
+	received [][]byte
+	headers  http.Header
+}
+
+func newTestIngestServer(t *testing.T, failures int) *testIngestServer {
+	srv := testIngestServer{failures: failures}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		srv.count++
+		if srv.count <= srv.failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		require.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		zr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(zr)
+		require.NoError(t, err)
+		srv.received = append(srv.received, body)
+		srv.headers = r.Header
+	}))
+	return &srv
+}
+
+func (s *testIngestServer) bodies() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]byte{}, s.received...)
+}
+
+func (s *testIngestServer) requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+func (s *testIngestServer) header(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.TrimSpace(s.headers.Get(key))
+}
@@ -0,0 +1,37 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/open-Q/common/golang/log"
+	"github.com/pkg/errors"
+)
+
+// FileSink represents report sink which appends the reports to the local file as JSON lines.
+// The file is able to be reopened after the rotation as log.FileWriter.
+type FileSink struct {
+	*log.FileWriter
+}
+
+// NewFileSink opens the report file for appending.
+func NewFileSink(path string, perm os.FileMode) (*FileSink, error) {
+	w, err := log.OpenFileWriter(path, perm)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open report file %s", path)
+	}
+	return &FileSink{w}, nil
+}
+
+// Send appends the report to the file.
+func (s *FileSink) Send(_ context.Context, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal report")
+	}
+	if _, err := s.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(err, "could not write report")
+	}
+	return nil
+}
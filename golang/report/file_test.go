@@ -0,0 +1,51 @@
+package report
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSink_Send(t *testing.T) {
+	fPath := path.Join(os.TempDir(), "report.jsonl")
+	defer func() {
+		err := os.Remove(fPath)
+		require.NoError(t, err)
+	}()
+
+	s, err := NewFileSink(fPath, 0644)
+	require.NoError(t, err)
+	r := New(Config{Service: "user"}, s)
+	for _, msg := range []string{"first", "second"} {
+		require.True(t, r.ReportPanic(context.Background(), msg, nil))
+		// the panics without stack have the same fingerprint.
+		r.limitMu.Lock()
+		r.seen = make(map[string]*seenEvent)
+		r.limitMu.Unlock()
+	}
+	require.NoError(t, r.Close())
+
+	f, err := os.Open(fPath)
+	require.NoError(t, err)
+	defer f.Close()
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		events = append(events, e)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, events, 2)
+	require.Equal(t, "panic: first", events[0].Message)
+	require.Equal(t, "panic: second", events[1].Message)
+	require.Equal(t, "user", events[1].Service)
+
+	_, err = NewFileSink(path.Join(os.TempDir(), "missing", "report.jsonl"), 0644)
+	require.Error(t, err)
+}
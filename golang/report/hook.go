@@ -0,0 +1,70 @@
+package report
+
+import (
+	"context"
+
+	"github.com/micro/go-micro/v2/server"
+	"github.com/open-Q/common/golang/log"
+	"github.com/sirupsen/logrus"
+)
+
+// panicFields are the fields of the panic entries, which are not added to the reports.
+var panicFields = []string{log.FieldPanic, log.FieldStack, log.FieldGoroutine, log.FieldService, log.FieldVersion}
+
+// Levels returns the levels of the entries reported by the hook.
+func (r *Reporter) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+}
+
+// Fire reports the recovered panics logged by log.Logger and the entries
+// with the unexpected error in the logrus.ErrorKey field.
+// The fatal entries are sent before returning since the process is going to exit.
+func (r *Reporter) Fire(entry *logrus.Entry) error {
+	level := LevelError
+	if entry.Level <= logrus.FatalLevel {
+		level = LevelFatal
+	}
+
+	var e *Event
+	if _, ok := entry.Data[log.FieldPanic]; ok {
+		stack, _ := entry.Data[log.FieldStack].(string)
+		fields := make(logrus.Fields, len(entry.Data))
+		for k, v := range entry.Data {
+			fields[k] = v
+		}
+		for _, k := range panicFields {
+			delete(fields, k)
+		}
+		e = r.newPanicEvent(fields, entry.Message, []byte(stack), level)
+	} else {
+		err, ok := entry.Data[logrus.ErrorKey].(error)
+		if !ok || !r.cfg.Unexpected(err) {
+			return nil
+		}
+		fields := make(logrus.Fields, len(entry.Data))
+		for k, v := range entry.Data {
+			if k != logrus.ErrorKey {
+				fields[k] = v
+			}
+		}
+		e = r.newErrorEvent(fields, err, level)
+	}
+
+	if r.enqueue(e) && level == LevelFatal {
+		r.Flush()
+	}
+	return nil
+}
+
+// HandlerWrapper returns go-micro handler wrapper, which reports the unexpected errors
+// returned by the handler. It must be added after the wrappers converting
+// the errors to go-micro errors, e.g. errors.HandlerWrapper.
+func (r *Reporter) HandlerWrapper() server.HandlerWrapper {
+	return func(fn server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			err := fn(ctx, req, rsp)
+			r.Report(ctx, err)
+			return err
+		}
+	}
+}
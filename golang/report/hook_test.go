@@ -0,0 +1,77 @@
+package report
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/micro/go-micro/v2/server"
+	errs "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/common/golang/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReporter_Fire(t *testing.T) {
+	sink := &memSink{}
+	r := New(Config{Service: "user"}, sink)
+	l := log.New(logrus.New())
+	l.SetOutput(ioutil.Discard)
+	l.AddHook(r)
+	ctx := log.ContextWithFields(context.Background(), logrus.Fields{"request_id": "42"})
+
+	err := func() (err error) {
+		defer l.Recover(ctx, &err)
+		panicking()
+		return nil
+	}()
+	require.Error(t, err)
+	l.WithError(newStorageUnknownError()).WithFields(logrus.Fields{"user_id": 7, "password": "hunter2"}).Error("could not get user")
+	l.WithError(errs.NewStorageNotFoundError("user")).Error("could not get user")
+	l.WithError(newStorageUnknownError()).Warn("could not get user")
+	require.NoError(t, r.Close())
+
+	events := sink.Events()
+	require.Len(t, events, 2)
+	require.Equal(t, "panic", events[0].Type)
+	require.Equal(t, "panic: boom", events[0].Message)
+	require.Equal(t, "42", events[0].Fields["request_id"])
+	require.NotContains(t, events[0].Fields, log.FieldStack)
+	require.True(t, strings.HasSuffix(events[0].Stack[0].Function, "report.panicking"), events[0].Stack[0].Function)
+
+	require.Equal(t, "errors.StorageUnknownError", events[1].Type)
+	require.Equal(t, LevelError, events[1].Level)
+	require.Equal(t, 7, events[1].Fields["user_id"])
+	require.Equal(t, "***", events[1].Fields["password"])
+	require.NotContains(t, events[1].Fields, logrus.ErrorKey)
+}
+
+func TestReporter_FireFatal(t *testing.T) {
+	sink := &memSink{}
+	r := New(Config{}, sink)
+	defer r.Close()
+
+	entry := logrus.NewEntry(logrus.New()).WithError(newStorageUnknownError())
+	entry.Level = logrus.FatalLevel
+	require.NoError(t, r.Fire(entry))
+
+	// the fatal entries are sent before returning.
+	events := sink.Events()
+	require.Len(t, events, 1)
+	require.Equal(t, LevelFatal, events[0].Level)
+}
+
+func TestReporter_HandlerWrapper(t *testing.T) {
+	sink := &memSink{}
+	r := New(Config{}, sink)
+
+	handlerErr := errors.Wrap(newStorageUnknownError(), "could not get user")
+	fn := r.HandlerWrapper()(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		return handlerErr
+	})
+	require.Equal(t, handlerErr, fn(context.Background(), nil, nil))
+	require.NoError(t, r.Close())
+	require.Len(t, sink.Events(), 1)
+}
@@ -0,0 +1,477 @@
+// Package report reports the unexpected errors and the recovered panics
+// to the error trackers through the pluggable sinks.
+//
+// The reports are fingerprinted by the error type and stack, the duplicates
+// are suppressed within the dedup window and the number of the reports is rate limited.
+package report
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	errs "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/common/golang/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultDedupWindow = time.Minute
+	defaultRateLimit   = 60
+	defaultBufferSize  = 100
+	defaultSendTimeout = 10 * time.Second
+	// maxSeenFingerprints is the number of the fingerprints, which triggers the cleanup of the expired ones.
+	maxSeenFingerprints = 1024
+)
+
+// There are report levels.
+const (
+	LevelError = "error"
+	LevelFatal = "fatal"
+)
+
+// panicType is the type of the panic reports.
+const panicType = "panic"
+
+// Frame represents the stack frame of the report.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// Event represents the error report.
+type Event struct {
+	ID          string                 `json:"id"`
+	Time        time.Time              `json:"time"`
+	Level       string                 `json:"level"`
+	Type        string                 `json:"type"`
+	Message     string                 `json:"message"`
+	Code        string                 `json:"code,omitempty"`
+	Fingerprint string                 `json:"fingerprint"`
+	Service     string                 `json:"service,omitempty"`
+	Version     string                 `json:"version,omitempty"`
+	Fields      map[string]interface{} `json:"fields,omitempty"`
+	// Stack contains the frames from the innermost one.
+	Stack []Frame `json:"stack,omitempty"`
+	// Suppressed is the number of the duplicates suppressed since the previous report.
+	Suppressed int `json:"suppressed,omitempty"`
+}
+
+// Sink represents the destination of the reports, e.g. the error tracker.
+// The sink is closed by Reporter.Close if it implements io.Closer.
+type Sink interface {
+	Send(ctx context.Context, event *Event) error
+}
+
+// Config represents Reporter configuration.
+type Config struct {
+	// Service is the service name added to the reports.
+	Service string
+	// Version is the service version added to the reports.
+	Version string
+	// DedupWindow is the period the reports with the same fingerprint are suppressed, 1m by default.
+	DedupWindow time.Duration
+	// RateLimit is the max number of the reports per minute, 60 by default.
+	// Negative value disables the limit.
+	RateLimit int
+	// BufferSize is the max number of the reports waiting for the sending, 100 by default.
+	// The reports are dropped when the buffer is full.
+	BufferSize int
+	// SendTimeout is the timeout of the report sending, 10s by default.
+	SendTimeout time.Duration
+	// Unexpected classifies the errors to report, IsUnexpected by default.
+	Unexpected func(err error) bool
+	// OnError is called on the sink errors.
+	OnError func(err error)
+	// Redactor masks sensitive data of the report fields and message,
+	// the redactor with log.DefaultRedactConfig rules by default.
+	Redactor *log.Redactor
+}
+
+// Reporter represents the error reporter, which sends the reports to the sinks
+// in the background goroutine. Close must be called on shutdown to send the queued reports.
+type Reporter struct {
+	cfg     Config
+	sinks   []Sink
+	queue   chan *Event
+	flushes chan chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+	dropped uint64
+	now     func() time.Time
+
+	limitMu sync.Mutex
+	seen    map[string]*seenEvent
+	window  time.Time
+	sent    int
+}
+
+// seenEvent represents the last report with the fingerprint.
+type seenEvent struct {
+	time       time.Time
+	suppressed int
+}
+
+// stackTracer represents the error with the stack trace of github.com/pkg/errors.
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+// New creates new Reporter instance and starts its sending.
+func New(cfg Config, sinks ...Sink) *Reporter {
+	if cfg.DedupWindow <= 0 {
+		cfg.DedupWindow = defaultDedupWindow
+	}
+	if cfg.RateLimit == 0 {
+		cfg.RateLimit = defaultRateLimit
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultBufferSize
+	}
+	if cfg.SendTimeout <= 0 {
+		cfg.SendTimeout = defaultSendTimeout
+	}
+	if cfg.Unexpected == nil {
+		cfg.Unexpected = IsUnexpected
+	}
+	if cfg.Redactor == nil {
+		cfg.Redactor = log.NewRedactor(log.DefaultRedactConfig())
+	}
+
+	r := Reporter{
+		cfg:     cfg,
+		sinks:   sinks,
+		queue:   make(chan *Event, cfg.BufferSize),
+		flushes: make(chan chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		now:     time.Now,
+		seen:    make(map[string]*seenEvent),
+	}
+	go r.run()
+
+	return &r
+}
+
+// IsUnexpected checks if the error is unexpected and should be reported:
+// the unknown storage errors except the temporary ones and the recovered panics.
+func IsUnexpected(err error) bool {
+	var panicErr *log.PanicError
+	if errors.As(err, &panicErr) {
+		return true
+	}
+	return errors.Is(err, errs.ErrStorageUnknown) && !errors.Is(err, errs.ErrStorageTemporary)
+}
+
+// Report reports the error if it's unexpected. The request fields of the context
+// and the fields of the error are added to the report.
+// It returns true if the report is queued.
+func (r *Reporter) Report(ctx context.Context, err error) bool {
+	if err == nil || !r.cfg.Unexpected(err) {
+		return false
+	}
+	var panicErr *log.PanicError
+	if errors.As(err, &panicErr) {
+		return r.ReportPanic(ctx, panicErr.Value, panicErr.Stack)
+	}
+	return r.enqueue(r.newErrorEvent(log.FieldsFromContext(ctx), err, LevelError))
+}
+
+// ReportPanic reports the recovered panic with the stack returned by debug.Stack.
+// It returns true if the report is queued.
+func (r *Reporter) ReportPanic(ctx context.Context, value interface{}, stack []byte) bool {
+	return r.enqueue(r.newPanicEvent(log.FieldsFromContext(ctx), fmt.Sprintf("panic: %v", value), stack, LevelError))
+}
+
+// Dropped returns the number of the reports dropped because of the full buffer.
+func (r *Reporter) Dropped() uint64 {
+	return atomic.LoadUint64(&r.dropped)
+}
+
+// Flush waits for the sending of the queued reports.
+func (r *Reporter) Flush() {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+	done := make(chan struct{})
+	r.flushes <- done
+	<-done
+}
+
+// Close sends the queued reports, stops the sending and closes the sinks.
+func (r *Reporter) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.done)
+	r.mu.Unlock()
+	<-r.stopped
+
+	var err error
+	for i := range r.sinks {
+		if c, ok := r.sinks[i].(io.Closer); ok {
+			err = errs.Append(err, c.Close())
+		}
+	}
+	return err
+}
+
+func (r *Reporter) run() {
+	defer close(r.stopped)
+	for {
+		select {
+		case e := <-r.queue:
+			r.send(e)
+		case done := <-r.flushes:
+			r.drain()
+			close(done)
+		case <-r.done:
+			r.drain()
+			return
+		}
+	}
+}
+
+func (r *Reporter) drain() {
+	for {
+		select {
+		case e := <-r.queue:
+			r.send(e)
+		default:
+			return
+		}
+	}
+}
+
+func (r *Reporter) send(e *Event) {
+	for i := range r.sinks {
+		ctx, cancel := context.WithTimeout(context.Background(), r.cfg.SendTimeout)
+		err := r.sinks[i].Send(ctx, e)
+		cancel()
+		if err != nil && r.cfg.OnError != nil {
+			r.cfg.OnError(errors.Wrapf(err, "could not send report %s", e.ID))
+		}
+	}
+}
+
+// enqueue queues the event unless it's a duplicate or the rate limit is exceeded.
+func (r *Reporter) enqueue(e *Event) bool {
+	if !r.allow(e) {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return false
+	}
+	select {
+	case r.queue <- e:
+		return true
+	default:
+		atomic.AddUint64(&r.dropped, 1)
+		return false
+	}
+}
+
+// allow checks the dedup window and the rate limit of the event.
+func (r *Reporter) allow(e *Event) bool {
+	r.limitMu.Lock()
+	defer r.limitMu.Unlock()
+
+	now := e.Time
+	if last, ok := r.seen[e.Fingerprint]; ok && now.Sub(last.time) < r.cfg.DedupWindow {
+		last.suppressed++
+		return false
+	}
+	if r.cfg.RateLimit > 0 {
+		if now.Sub(r.window) >= time.Minute {
+			r.window = now
+			r.sent = 0
+		}
+		if r.sent >= r.cfg.RateLimit {
+			return false
+		}
+		r.sent++
+	}
+
+	if last, ok := r.seen[e.Fingerprint]; ok {
+		e.Suppressed = last.suppressed
+	}
+	r.seen[e.Fingerprint] = &seenEvent{time: now}
+	if len(r.seen) > maxSeenFingerprints {
+		for fp, last := range r.seen {
+			if now.Sub(last.time) >= r.cfg.DedupWindow {
+				delete(r.seen, fp)
+			}
+		}
+	}
+	return true
+}
+
+func (r *Reporter) newErrorEvent(fields logrus.Fields, err error, level string) *Event {
+	if errFields := errs.FieldsOf(err); len(errFields) > 0 {
+		merged := make(logrus.Fields, len(fields)+len(errFields))
+		for k, v := range fields {
+			merged[k] = v
+		}
+		for k, v := range errFields {
+			merged[k] = v
+		}
+		fields = merged
+	}
+	e := r.newEvent(fields, level)
+	e.Message = r.cfg.Redactor.String(err.Error())
+	e.Code = string(errs.CodeOf(err))
+	e.Type = fmt.Sprintf("%T", rootCause(err))
+	if st := innermostStackTracer(err); st != nil {
+		e.Type = fmt.Sprintf("%T", st)
+		e.Stack = framesOf(st.StackTrace())
+	}
+	e.Fingerprint = fingerprint(e.Type, e.Stack)
+	return e
+}
+
+func (r *Reporter) newPanicEvent(fields logrus.Fields, msg string, stack []byte, level string) *Event {
+	e := r.newEvent(fields, level)
+	e.Message = r.cfg.Redactor.String(msg)
+	e.Type = panicType
+	e.Stack = parseStack(stack)
+	e.Fingerprint = fingerprint(e.Type, e.Stack)
+	return e
+}
+
+func (r *Reporter) newEvent(fields logrus.Fields, level string) *Event {
+	e := Event{
+		ID:      newEventID(),
+		Time:    r.now().UTC(),
+		Level:   level,
+		Service: r.cfg.Service,
+		Version: r.cfg.Version,
+		Fields:  r.cfg.Redactor.Fields(fields),
+	}
+	return &e
+}
+
+// innermostStackTracer returns the innermost error of the chain with the stack trace,
+// which is the closest one to the error origin. The package level sentinel errors
+// created by github.com/pkg/errors are skipped since their stack is the package initialization.
+func innermostStackTracer(err error) stackTracer {
+	var found stackTracer
+	for ; err != nil; err = errors.Unwrap(err) {
+		st, ok := err.(stackTracer)
+		if !ok {
+			continue
+		}
+		frames := framesOf(st.StackTrace())
+		if len(frames) == 0 || isInitFunction(frames[0].Function) {
+			continue
+		}
+		found = st
+	}
+	return found
+}
+
+// isInitFunction checks if the function is the package initialization.
+func isInitFunction(name string) bool {
+	return strings.HasSuffix(name, ".init") || strings.Contains(name, ".init.")
+}
+
+// rootCause returns the innermost error of the chain.
+func rootCause(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
+
+func framesOf(st errors.StackTrace) []Frame {
+	frames := make([]Frame, 0, len(st))
+	for _, f := range st {
+		pc := uintptr(f) - 1
+		fn := runtime.FuncForPC(pc)
+		if fn == nil {
+			continue
+		}
+		file, line := fn.FileLine(pc)
+		frames = append(frames, Frame{
+			Function: fn.Name(),
+			File:     file,
+			Line:     line,
+		})
+	}
+	return frames
+}
+
+// parseStack parses the stack returned by debug.Stack. The frames of runtime/debug
+// and the panic itself are skipped, so the first frame is the panicking function.
+func parseStack(stack []byte) []Frame {
+	lines := strings.Split(strings.TrimSpace(string(stack)), "\n")
+	var frames []Frame
+	// the first line is the goroutine header, then there are function and file lines.
+	for i := 1; i+1 < len(lines); i += 2 {
+		function := lines[i]
+		if j := strings.LastIndex(function, "("); j > 0 {
+			function = function[:j]
+		}
+		location := strings.TrimSpace(lines[i+1])
+		if j := strings.LastIndex(location, " +0x"); j > 0 {
+			location = location[:j]
+		}
+		var line int
+		file := location
+		if j := strings.LastIndex(location, ":"); j > 0 {
+			file = location[:j]
+			_, _ = fmt.Sscanf(location[j+1:], "%d", &line)
+		}
+		frames = append(frames, Frame{
+			Function: function,
+			File:     file,
+			Line:     line,
+		})
+	}
+	// skip the frames up to the panic call.
+	for i := range frames {
+		if frames[i].Function == "panic" || strings.HasPrefix(frames[i].Function, "runtime.gopanic") {
+			return frames[i+1:]
+		}
+	}
+	return frames
+}
+
+// fingerprint returns the hash of the error type and the stack frames.
+func fingerprint(typ string, frames []Frame) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, typ)
+	for i := range frames {
+		_, _ = fmt.Fprintf(h, "\n%s:%d", frames[i].Function, frames[i].Line)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// newEventID returns random 32 hex chars event ID.
+func newEventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
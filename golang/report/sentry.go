@@ -0,0 +1,232 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	sentryContentType    = "application/x-sentry-envelope"
+	sentryVersion        = 7
+	sentryClient         = "open-q-report/1.0"
+	sentryPlatform       = "go"
+	defaultSentryTimeout = 10 * time.Second
+)
+
+// SentrySinkConfig represents SentrySink configuration.
+type SentrySinkConfig struct {
+	// DSN is the Sentry project DSN, e.g. https://key@sentry.example.com/42.
+	DSN string
+	// Environment is the environment of the events, e.g. production.
+	Environment string
+	// ServerName is the host name of the events.
+	ServerName string
+	// Client is the HTTP client, the client with 10s timeout by default.
+	Client *http.Client
+}
+
+// SentrySink represents report sink which sends the reports to the Sentry
+// compatible error tracker as envelopes.
+type SentrySink struct {
+	cfg      SentrySinkConfig
+	endpoint string
+	auth     string
+}
+
+// sentryEvent represents Sentry event payload.
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Logger      string                 `json:"logger,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Message     string                 `json:"message,omitempty"`
+	Fingerprint []string               `json:"fingerprint,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Exception   sentryExceptions       `json:"exception"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+}
+
+// NewSentrySink creates new SentrySink instance.
+func NewSentrySink(cfg SentrySinkConfig) (*SentrySink, error) {
+	if cfg.DSN == "" {
+		return nil, errors.New("sentry dsn is required")
+	}
+	dsn, err := url.Parse(cfg.DSN)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid sentry dsn")
+	}
+	key := dsn.User.Username()
+	path := strings.Trim(dsn.Path, "/")
+	i := strings.LastIndex(path, "/")
+	project := path[i+1:]
+	if dsn.Host == "" || key == "" || project == "" {
+		return nil, errors.New("invalid sentry dsn: host, key and project are required")
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: defaultSentryTimeout}
+	}
+
+	prefix := ""
+	if i > 0 {
+		prefix = "/" + path[:i]
+	}
+	endpoint := url.URL{
+		Scheme: dsn.Scheme,
+		Host:   dsn.Host,
+		Path:   fmt.Sprintf("%s/api/%s/envelope/", prefix, project),
+	}
+
+	return &SentrySink{
+		cfg:      cfg,
+		endpoint: endpoint.String(),
+		auth: fmt.Sprintf("Sentry sentry_version=%d, sentry_client=%s, sentry_key=%s",
+			sentryVersion, sentryClient, key),
+	}, nil
+}
+
+// Send sends the report as Sentry envelope.
+func (s *SentrySink) Send(ctx context.Context, event *Event) error {
+	payload, err := s.envelope(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "could not create sentry request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", sentryContentType)
+	req.Header.Set("X-Sentry-Auth", s.auth)
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "could not send sentry envelope")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+	return errors.Errorf("could not send sentry envelope: unexpected status %d", resp.StatusCode)
+}
+
+// envelope returns the envelope of the event: the envelope header,
+// the item header and the event payload separated by new lines.
+func (s *SentrySink) envelope(event *Event) ([]byte, error) {
+	item, err := json.Marshal(s.event(event))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal sentry event")
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if err := enc.Encode(map[string]string{
+		"event_id": event.ID,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+		"dsn":      s.cfg.DSN,
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not marshal sentry envelope header")
+	}
+	if err := enc.Encode(map[string]interface{}{
+		"type":   "event",
+		"length": len(item),
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not marshal sentry item header")
+	}
+	buf.Write(item)
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
+
+func (s *SentrySink) event(event *Event) *sentryEvent {
+	e := sentryEvent{
+		EventID:     event.ID,
+		Timestamp:   event.Time.UTC().Format(time.RFC3339Nano),
+		Level:       event.Level,
+		Platform:    sentryPlatform,
+		Logger:      event.Service,
+		Environment: s.cfg.Environment,
+		ServerName:  s.cfg.ServerName,
+		Message:     event.Message,
+		Fingerprint: []string{event.Fingerprint},
+		Tags:        make(map[string]string),
+		Extra:       event.Fields,
+		Exception: sentryExceptions{
+			Values: []sentryException{{
+				Type:  event.Type,
+				Value: event.Message,
+			}},
+		},
+	}
+	if event.Service != "" {
+		e.Tags["service"] = event.Service
+		e.Release = event.Service
+		if event.Version != "" {
+			e.Release += "@" + event.Version
+		}
+	}
+	if event.Version != "" {
+		e.Tags["version"] = event.Version
+	}
+	if event.Code != "" {
+		e.Tags["code"] = event.Code
+	}
+	if event.Suppressed > 0 {
+		e.Extra = make(map[string]interface{}, len(event.Fields)+1)
+		for k, v := range event.Fields {
+			e.Extra[k] = v
+		}
+		e.Extra["suppressed"] = event.Suppressed
+	}
+	if len(event.Stack) > 0 {
+		// Sentry expects the frames from the outermost one.
+		frames := make([]sentryFrame, len(event.Stack))
+		for i := range event.Stack {
+			f := event.Stack[len(event.Stack)-1-i]
+			frames[i] = sentryFrame{
+				Function: f.Function,
+				AbsPath:  f.File,
+				Lineno:   f.Line,
+			}
+		}
+		e.Exception.Values[0].Stacktrace = &sentryStacktrace{Frames: frames}
+	}
+	return &e
+}
@@ -0,0 +1,137 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewSentrySink(t *testing.T) {
+	tt := []struct {
+		name        string
+		dsn         string
+		expEndpoint string
+		expErr      string
+	}{
+		{
+			name:   "empty dsn error",
+			expErr: "sentry dsn is required",
+		},
+		{
+			name:   "missing key error",
+			dsn:    "https://sentry.example.com/42",
+			expErr: "invalid sentry dsn: host, key and project are required",
+		},
+		{
+			name:   "missing project error",
+			dsn:    "https://key@sentry.example.com",
+			expErr: "invalid sentry dsn: host, key and project are required",
+		},
+		{
+			name:        "all ok",
+			dsn:         "https://key@sentry.example.com/42",
+			expEndpoint: "https://sentry.example.com/api/42/envelope/",
+		},
+		{
+			name:        "path prefix",
+			dsn:         "http://key@localhost:9000/sentry/42",
+			expEndpoint: "http://localhost:9000/sentry/api/42/envelope/",
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewSentrySink(SentrySinkConfig{DSN: tc.dsn})
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expEndpoint, s.endpoint)
+			require.Contains(t, s.auth, "sentry_key=key")
+		})
+	}
+}
+
+func TestSentrySink_Send(t *testing.T) {
+	var (
+		header http.Header
+		path   string
+		body   []byte
+		status = http.StatusOK
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		path = r.URL.Path
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	dsn := strings.Replace(srv.URL, "://", "://public@", 1) + "/42"
+	s, err := NewSentrySink(SentrySinkConfig{DSN: dsn, Environment: "test"})
+	require.NoError(t, err)
+
+	event := Event{
+		ID:          "0123456789abcdef0123456789abcdef",
+		Time:        time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC),
+		Level:       LevelError,
+		Type:        "errors.StorageUnknownError",
+		Message:     "unknown error: connection reset",
+		Code:        "STORAGE_UNKNOWN",
+		Fingerprint: "abc",
+		Service:     "user",
+		Version:     "0.0.1",
+		Fields:      map[string]interface{}{"request_id": "42"},
+		Stack: []Frame{
+			{Function: "main.inner", File: "main.go", Line: 10},
+			{Function: "main.main", File: "main.go", Line: 20},
+		},
+		Suppressed: 3,
+	}
+	require.NoError(t, s.Send(context.Background(), &event))
+	require.Equal(t, "/api/42/envelope/", path)
+	require.Equal(t, sentryContentType, header.Get("Content-Type"))
+	require.Contains(t, header.Get("X-Sentry-Auth"), "sentry_key=public")
+
+	lines := bytes.Split(bytes.TrimSpace(body), []byte("\n"))
+	require.Len(t, lines, 3)
+	var envelopeHeader map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[0], &envelopeHeader))
+	require.Equal(t, event.ID, envelopeHeader["event_id"])
+	require.Equal(t, dsn, envelopeHeader["dsn"])
+	var itemHeader map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[1], &itemHeader))
+	require.Equal(t, "event", itemHeader["type"])
+	require.Equal(t, float64(len(lines[2])), itemHeader["length"])
+
+	var e sentryEvent
+	require.NoError(t, json.Unmarshal(lines[2], &e))
+	require.Equal(t, event.ID, e.EventID)
+	require.Equal(t, "2020-10-01T12:00:00Z", e.Timestamp)
+	require.Equal(t, "go", e.Platform)
+	require.Equal(t, "user@0.0.1", e.Release)
+	require.Equal(t, "test", e.Environment)
+	require.Equal(t, []string{"abc"}, e.Fingerprint)
+	require.Equal(t, map[string]string{"service": "user", "version": "0.0.1", "code": "STORAGE_UNKNOWN"}, e.Tags)
+	require.Equal(t, map[string]interface{}{"request_id": "42", "suppressed": float64(3)}, e.Extra)
+	require.Len(t, e.Exception.Values, 1)
+	require.Equal(t, event.Type, e.Exception.Values[0].Type)
+	require.Equal(t, []sentryFrame{
+		{Function: "main.main", AbsPath: "main.go", Lineno: 20},
+		{Function: "main.inner", AbsPath: "main.go", Lineno: 10},
+	}, e.Exception.Values[0].Stacktrace.Frames)
+	// the event fields are not modified.
+	require.NotContains(t, event.Fields, "suppressed")
+
+	status = http.StatusTooManyRequests
+	require.EqualError(t, s.Send(context.Background(), &event), "could not send sentry envelope: unexpected status 429")
+}
@@ -0,0 +1,136 @@
+// Package retry retries the operations failed with the retryable errors
+// using exponential backoff with jitter.
+//
+// The errors are classified by errors.IsRetryable of the common errors package,
+// so the typed storage errors of storage.MongoCollection could be retried as is:
+//
+//	err := retry.Do(ctx, retry.Config{}, func(ctx context.Context) error {
+//		_, err := coll.InsertOne(ctx, doc)
+//		return err
+//	})
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	errs "github.com/open-Q/common/golang/errors"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultInitialInterval = 100 * time.Millisecond
+	defaultMaxInterval     = 10 * time.Second
+	defaultMultiplier      = 2
+	defaultJitter          = 0.2
+	defaultMaxElapsedTime  = time.Minute
+)
+
+// Config represents retry configuration.
+type Config struct {
+	// InitialInterval is the delay before the first retry, 100ms by default.
+	InitialInterval time.Duration
+	// MaxInterval is the max delay between the attempts, 10s by default.
+	MaxInterval time.Duration
+	// Multiplier is the delay growth factor, 2 by default.
+	Multiplier float64
+	// Jitter is the randomization factor of the delay, 0.2 by default:
+	// the delay is random in [delay-0.2*delay, delay+0.2*delay].
+	// Negative value disables the jitter.
+	Jitter float64
+	// MaxElapsedTime is the max time of all the attempts, 1m by default.
+	// Negative value disables the limit.
+	MaxElapsedTime time.Duration
+	// MaxAttempts is the max number of the attempts, unlimited by default.
+	MaxAttempts int
+	// Retryable classifies the errors, errors.IsRetryable by default.
+	Retryable func(err error) bool
+}
+
+var (
+	randMu sync.Mutex
+	random = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// Do calls fn until it succeeds, fails with not retryable error or the retries are exhausted.
+// Not retryable error is returned as is. The last error is returned wrapped
+// when the max attempts or the max elapsed time is reached or the context is done.
+func Do(ctx context.Context, cfg Config, fn func(ctx context.Context) error) error {
+	cfg = withDefaults(cfg)
+	start := time.Now()
+	interval := cfg.InitialInterval
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if !cfg.Retryable(err) {
+			return err
+		}
+		if cfg.MaxAttempts > 0 && attempt >= cfg.MaxAttempts {
+			return errors.Wrapf(err, "gave up after %d attempts", attempt)
+		}
+
+		delay := jitter(interval, cfg.Jitter)
+		if cfg.MaxElapsedTime > 0 && time.Since(start)+delay > cfg.MaxElapsedTime {
+			return errors.Wrapf(err, "gave up after %d attempts in %s", attempt, time.Since(start).Round(time.Millisecond))
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Wrapf(err, "gave up after %d attempts: %v", attempt, ctx.Err())
+		case <-timer.C:
+		}
+
+		interval = time.Duration(float64(interval) * cfg.Multiplier)
+		if interval > cfg.MaxInterval {
+			interval = cfg.MaxInterval
+		}
+	}
+}
+
+func withDefaults(cfg Config) Config {
+	if cfg.InitialInterval <= 0 {
+		cfg.InitialInterval = defaultInitialInterval
+	}
+	if cfg.MaxInterval <= 0 {
+		cfg.MaxInterval = defaultMaxInterval
+	}
+	if cfg.MaxInterval < cfg.InitialInterval {
+		cfg.MaxInterval = cfg.InitialInterval
+	}
+	if cfg.Multiplier < 1 {
+		cfg.Multiplier = defaultMultiplier
+	}
+	if cfg.Jitter == 0 {
+		cfg.Jitter = defaultJitter
+	}
+	if cfg.Jitter > 1 {
+		cfg.Jitter = 1
+	}
+	if cfg.MaxElapsedTime == 0 {
+		cfg.MaxElapsedTime = defaultMaxElapsedTime
+	}
+	if cfg.Retryable == nil {
+		cfg.Retryable = errs.IsRetryable
+	}
+	return cfg
+}
+
+// jitter returns the random delay in [interval-factor*interval, interval+factor*interval].
+func jitter(interval time.Duration, factor float64) time.Duration {
+	if factor <= 0 {
+		return interval
+	}
+	randMu.Lock()
+	r := random.Float64()
+	randMu.Unlock()
+	delta := factor * float64(interval)
+	return time.Duration(float64(interval) - delta + r*2*delta)
+}
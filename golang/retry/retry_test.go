@@ -0,0 +1,139 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	errs "github.com/open-Q/common/golang/errors"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Do(t *testing.T) {
+	temporaryErr := errs.NewStorageTemporaryError("user")
+	tt := []struct {
+		name        string
+		cfg         Config
+		errs        []error
+		expAttempts int
+		expErr      string
+	}{
+		{
+			name:        "success",
+			errs:        []error{nil},
+			expAttempts: 1,
+		},
+		{
+			name:        "success after retries",
+			errs:        []error{temporaryErr, temporaryErr, nil},
+			expAttempts: 3,
+		},
+		{
+			name:        "not retryable error",
+			errs:        []error{temporaryErr, errs.NewStorageFindError("user")},
+			expAttempts: 2,
+			expErr:      "could not find value: user",
+		},
+		{
+			name:        "permanent error",
+			errs:        []error{errs.Permanent(temporaryErr)},
+			expAttempts: 1,
+			expErr:      "unknown error: temporary failure: user",
+		},
+		{
+			name:        "max attempts",
+			cfg:         Config{MaxAttempts: 3},
+			errs:        []error{temporaryErr, temporaryErr, temporaryErr, nil},
+			expAttempts: 3,
+			expErr:      "gave up after 3 attempts: unknown error: temporary failure: user",
+		},
+		{
+			name: "custom classifier",
+			cfg: Config{Retryable: func(err error) bool {
+				return errs.IsNotFound(err)
+			}},
+			errs:        []error{errs.NewNotFoundError("user"), temporaryErr},
+			expAttempts: 2,
+			expErr:      "unknown error: temporary failure: user",
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.InitialInterval = time.Millisecond
+			attempts := 0
+			err := Do(context.Background(), tc.cfg, func(ctx context.Context) error {
+				err := tc.errs[attempts]
+				attempts++
+				return err
+			})
+			require.Equal(t, tc.expAttempts, attempts)
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_DoMaxElapsedTime(t *testing.T) {
+	attempts := 0
+	err := Do(context.Background(), Config{
+		InitialInterval: 10 * time.Millisecond,
+		Jitter:          -1,
+		MaxElapsedTime:  25 * time.Millisecond,
+	}, func(ctx context.Context) error {
+		attempts++
+		return errs.NewUnavailableError("user")
+	})
+	require.Error(t, err)
+	require.True(t, errs.IsUnavailable(err))
+	require.Equal(t, 2, attempts)
+}
+
+func Test_DoContext(t *testing.T) {
+	t.Run("canceled before the first attempt", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := Do(ctx, Config{}, func(ctx context.Context) error {
+			return nil
+		})
+		require.True(t, errors.Is(err, context.Canceled))
+	})
+	t.Run("canceled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		attempts := 0
+		err := Do(ctx, Config{InitialInterval: time.Hour, MaxElapsedTime: -1}, func(ctx context.Context) error {
+			attempts++
+			cancel()
+			return errs.NewUnavailableError("user")
+		})
+		require.Equal(t, 1, attempts)
+		require.EqualError(t, err, "gave up after 1 attempts: context canceled: unavailable: user")
+		require.True(t, errs.IsUnavailable(err))
+	})
+}
+
+func Test_jitter(t *testing.T) {
+	require.Equal(t, time.Second, jitter(time.Second, 0))
+	for i := 0; i < 100; i++ {
+		d := jitter(time.Second, 0.5)
+		require.True(t, d >= 500*time.Millisecond && d <= 1500*time.Millisecond, d.String())
+	}
+}
+
+func Test_withDefaults(t *testing.T) {
+	cfg := withDefaults(Config{})
+	require.Equal(t, defaultInitialInterval, cfg.InitialInterval)
+	require.Equal(t, defaultMaxInterval, cfg.MaxInterval)
+	require.Equal(t, float64(defaultMultiplier), cfg.Multiplier)
+	require.Equal(t, defaultJitter, cfg.Jitter)
+	require.Equal(t, defaultMaxElapsedTime, cfg.MaxElapsedTime)
+	require.NotNil(t, cfg.Retryable)
+
+	cfg = withDefaults(Config{InitialInterval: time.Minute, MaxInterval: time.Second, Jitter: 2})
+	require.Equal(t, time.Minute, cfg.MaxInterval)
+	require.Equal(t, float64(1), cfg.Jitter)
+}
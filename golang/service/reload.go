@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/open-Q/common/golang/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LogLevelFlag is the name of the contract flag which controls logger level.
+	// The flag value has the "level[:revert]" format, e.g. "debug" or "debug:10m".
+	LogLevelFlag = "log-level"
+	// LogLevelsFlag is the name of the contract flag which overrides levels of the
+	// named sub-loggers by their name prefixes, e.g. "storage=debug,service=warn".
+	LogLevelsFlag = "log-levels"
+)
+
+// WatchContract checks the contract file every interval and calls fn
+// with the reloaded contract when the file has been modified.
+// fn receives an error if the modified contract could not be loaded.
+// Blocks until the context is done.
+func WatchContract(ctx context.Context, contractPath string, interval time.Duration, fn func(*Contract, error)) {
+	var modTime time.Time
+	if info, err := os.Stat(contractPath); err == nil {
+		modTime = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(contractPath)
+			if err != nil {
+				fn(nil, errors.Wrapf(err, "could not stat %s file", contractPath))
+				continue
+			}
+			if !info.ModTime().After(modTime) {
+				continue
+			}
+			modTime = info.ModTime()
+
+			contract, err := parseContractFile(contractPath)
+			if err != nil {
+				fn(nil, errors.Wrap(err, "contract error"))
+				continue
+			}
+			if err := contract.Validate(); err != nil {
+				fn(nil, errors.Wrap(err, "validation error"))
+				continue
+			}
+			fn(contract, nil)
+		}
+	}
+}
+
+// LogLevelReloader returns WatchContract function which applies
+// the LogLevelFlag and LogLevelsFlag values of the reloaded contract to the logger.
+// The levels are changed only if the flag values have been changed.
+func LogLevelReloader(logger *log.Logger) func(*Contract, error) {
+	var (
+		applied, appliedOverrides string
+		overridesApplied          bool
+	)
+	return func(contract *Contract, err error) {
+		if err != nil {
+			logger.WithError(err).Error("could not reload contract")
+			return
+		}
+
+		if value, ok := contract.flagValue(LogLevelFlag); ok && value != applied {
+			req := parseLevelRequest(value)
+			if err := logger.Apply(req); err != nil {
+				logger.WithError(err).Errorf("could not apply %s flag", LogLevelFlag)
+			} else {
+				applied = value
+				// the global level changes all the sub-loggers, so the overrides are applied again.
+				overridesApplied = false
+			}
+		}
+
+		if value, ok := contract.flagValue(LogLevelsFlag); ok && (!overridesApplied || value != appliedOverrides) {
+			if err := applyLevelOverrides(logger, value); err != nil {
+				logger.WithError(err).Errorf("could not apply %s flag", LogLevelsFlag)
+			} else {
+				appliedOverrides = value
+				overridesApplied = true
+			}
+		}
+	}
+}
+
+// flagValue returns the string value of the contract flag.
+func (c *Contract) flagValue(name string) (string, bool) {
+	for i := range c.Flags {
+		if c.Flags[i].Name == name && c.Flags[i].Value != nil {
+			return fmt.Sprint(c.Flags[i].Value), true
+		}
+	}
+	return "", false
+}
+
+func applyLevelOverrides(logger *log.Logger, value string) error {
+	overrides, err := log.ParseLevelOverrides(value)
+	if err != nil {
+		return err
+	}
+	logger.SetLevelOverrides(overrides)
+	return nil
+}
+
+func parseLevelRequest(value string) log.LevelRequest {
+	parts := strings.SplitN(value, ":", 2)
+	req := log.LevelRequest{
+		Level: strings.TrimSpace(parts[0]),
+	}
+	if len(parts) == 2 {
+		req.Revert = strings.TrimSpace(parts[1])
+	}
+	return req
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/open-Q/common/golang/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WatchContract(t *testing.T) {
+	fPath := path.Join(os.TempDir(), "watch.json")
+	err := writeTestContract(fPath, "info")
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(fPath)
+		require.NoError(t, err)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan *Contract, 1)
+	go WatchContract(ctx, fPath, 5*time.Millisecond, func(c *Contract, err error) {
+		require.NoError(t, err)
+		reloaded <- c
+	})
+
+	// wait until the watcher gets the initial modification time.
+	time.Sleep(20 * time.Millisecond)
+	err = writeTestContract(fPath, "debug")
+	require.NoError(t, err)
+	modTime := time.Now().Add(time.Second)
+	err = os.Chtimes(fPath, modTime, modTime)
+	require.NoError(t, err)
+
+	select {
+	case c := <-reloaded:
+		value, ok := c.flagValue(LogLevelFlag)
+		require.True(t, ok)
+		require.Equal(t, "debug", value)
+	case <-time.After(time.Second):
+		t.Fatal("contract has not been reloaded")
+	}
+}
+
+func Test_LogLevelReloader(t *testing.T) {
+	tt := []struct {
+		name     string
+		value    string
+		expLevel logrus.Level
+	}{
+		{
+			name:     "invalid level",
+			value:    "loud",
+			expLevel: logrus.InfoLevel,
+		},
+		{
+			name:     "level",
+			value:    "debug",
+			expLevel: logrus.DebugLevel,
+		},
+		{
+			name:     "level with revert",
+			value:    "warn:1h",
+			expLevel: logrus.WarnLevel,
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			logger := log.New(logrus.New())
+			logger.SetOutput(&nopWriter{})
+			reload := LogLevelReloader(logger)
+			reload(nil, errors.New("some error"))
+			reload(&Contract{
+				Flags: []Flag{
+					{
+						Type:  "string",
+						Name:  LogLevelFlag,
+						Value: tc.value,
+					},
+				},
+			}, nil)
+			require.Equal(t, tc.expLevel, logger.GetLevel())
+		})
+	}
+}
+
+func Test_LogLevelReloaderOverrides(t *testing.T) {
+	logger := log.New(logrus.New())
+	logger.SetOutput(&nopWriter{})
+	storage := logger.Named("storage").Named("mongo")
+	rpc := logger.Named("service")
+	reload := LogLevelReloader(logger)
+
+	contract := Contract{
+		Flags: []Flag{
+			{
+				Type:  "string",
+				Name:  LogLevelsFlag,
+				Value: "storage=debug,service=warn",
+			},
+		},
+	}
+	reload(&contract, nil)
+	require.Equal(t, logrus.InfoLevel, logger.GetLevel())
+	require.Equal(t, logrus.DebugLevel, storage.GetLevel())
+	require.Equal(t, logrus.WarnLevel, rpc.GetLevel())
+
+	// the global level change keeps the overrides.
+	contract.Flags = append(contract.Flags, Flag{
+		Type:  "string",
+		Name:  LogLevelFlag,
+		Value: "error",
+	})
+	reload(&contract, nil)
+	require.Equal(t, logrus.ErrorLevel, logger.GetLevel())
+	require.Equal(t, logrus.DebugLevel, storage.GetLevel())
+	require.Equal(t, logrus.WarnLevel, rpc.GetLevel())
+
+	// invalid overrides are not applied.
+	contract.Flags[0].Value = "storage"
+	reload(&contract, nil)
+	require.Equal(t, logrus.DebugLevel, storage.GetLevel())
+}
+
+func writeTestContract(fPath, level string) error {
+	data, err := json.Marshal(Contract{
+		Name: "test",
+		Config: Config{
+			Host: "127.0.0.1",
+		},
+		Flags: []Flag{
+			{
+				Type:  "string",
+				Name:  LogLevelFlag,
+				Value: level,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return createFileWithContent(fPath, data)
+}
+
+type nopWriter struct{}
+
+func (nopWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
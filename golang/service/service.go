@@ -10,21 +10,28 @@ import (
 
 	"github.com/micro/cli/v2"
 	micro "github.com/micro/go-micro/v2"
+	"github.com/micro/go-micro/v2/logger"
 	"github.com/micro/go-micro/v2/server"
+	errs "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/common/golang/log"
+	"github.com/open-Q/common/golang/report"
 	"github.com/pkg/errors"
 )
 
 const (
 	disableFlagCheckENV = "disable-flag-check"
+
+	logPerm os.FileMode = 0755
 )
 
 // Contract represents service contract configuration.
 type Contract struct {
-	Name        string `json:"service"`
-	Version     string `json:"version,omitempty"`
-	Description string `json:"description,omitempty"`
-	Config      Config `json:"config"`
-	Flags       []Flag `json:"flags,omitempty"`
+	Name        string     `json:"service"`
+	Version     string     `json:"version,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Config      Config     `json:"config"`
+	Flags       []Flag     `json:"flags,omitempty"`
+	Log         *LogConfig `json:"log,omitempty"`
 }
 
 // Config represents service configuration model.
@@ -34,6 +41,14 @@ type Config struct {
 	Meta map[string]string `json:"meta,omitempty"`
 }
 
+// LogConfig represents service logger configuration model.
+type LogConfig struct {
+	Folder string `json:"folder"`
+	File   string `json:"file"`
+	// Format is one of the log package formats: json (default), logfmt or ecs.
+	Format string `json:"format,omitempty"`
+}
+
 // Flag represents service flag model.
 type Flag struct {
 	Type         string      `json:"type,omitempty"`
@@ -45,23 +60,48 @@ type Flag struct {
 	EnvVariables []string    `json:"env,omitempty"`
 }
 
+// Option represents service option.
+type Option func(*options)
+
+type options struct {
+	logger   *log.Logger
+	reporter *report.Reporter
+}
+
+// WithLogger makes go-micro write its logs through the provided logger.
+// go-micro logs are written by the "micro" sub-logger.
+// Handler panics are logged and returned as internal server errors.
+func WithLogger(l *log.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithReporter makes the service report the unexpected errors returned by the handlers.
+// The logged panics are reported as well if the logger is provided by WithLogger.
+func WithReporter(r *report.Reporter) Option {
+	return func(o *options) {
+		o.reporter = r
+	}
+}
+
 // Validate validates service contract struct.
+// All the validation errors are returned at once as errors.MultiError.
 func (c *Contract) Validate() error {
+	var err error
 	if strings.TrimSpace(c.Name) == "" {
-		return errors.New("service name is required")
+		err = errs.Append(err, errors.New("service name is required"))
 	}
 
 	if strings.TrimSpace(c.Config.Host) == "" {
-		return errors.New("service host is required")
+		err = errs.Append(err, errors.New("service host is required"))
 	}
 
 	for i := range c.Flags {
-		if err := c.Flags[i].Validate(); err != nil {
-			return err
-		}
+		err = errs.Append(err, c.Flags[i].Validate())
 	}
 
-	return nil
+	return err
 }
 
 // Validate validates service flag struct.
@@ -74,7 +114,12 @@ func (sf *Flag) Validate() error {
 }
 
 // New creates new micro.Service instance by contract configuration.
-func New(contractPath string) (micro.Service, map[string]GenericFlag, error) {
+func New(contractPath string, opts ...Option) (micro.Service, map[string]GenericFlag, error) {
+	var o options
+	for i := range opts {
+		opts[i](&o)
+	}
+
 	// get service contract.
 	contract, err := parseContractFile(contractPath)
 	if err != nil {
@@ -88,7 +133,7 @@ func New(contractPath string) (micro.Service, map[string]GenericFlag, error) {
 
 	// create a new service instance.
 	cliFlags, flagsMap := generateServiceFlags(contract.Flags)
-	service := micro.NewService(
+	serviceOpts := []micro.Option{
 		micro.Name(contract.Name),
 		micro.Version(contract.Version),
 		micro.Metadata(contract.Config.Meta),
@@ -99,7 +144,27 @@ func New(contractPath string) (micro.Service, map[string]GenericFlag, error) {
 				server.Address(fmt.Sprintf("%s:%d", contract.Config.Host, contract.Config.Port)),
 			),
 		),
-	)
+		// send the storage errors as go-micro errors and restore them on the client side.
+		micro.WrapHandler(errs.HandlerWrapper()),
+		micro.WrapCall(errs.CallWrapper()),
+	}
+
+	// install the logger before the service creation to get all go-micro logs.
+	if o.logger != nil {
+		logger.DefaultLogger = log.NewMicroLogger(o.logger.Named("micro"))
+		// log handler panics and return them as internal server errors.
+		serviceOpts = append(serviceOpts, micro.WrapHandler(o.logger.HandlerWrapper()))
+	}
+
+	// report the handler errors before their conversion into go-micro errors.
+	if o.reporter != nil {
+		if o.logger != nil {
+			o.logger.AddHook(o.reporter)
+		}
+		serviceOpts = append(serviceOpts, micro.WrapHandler(o.reporter.HandlerWrapper()))
+	}
+
+	service := micro.NewService(serviceOpts...)
 
 	// parse the command line flags.
 	initService(service)
@@ -107,6 +172,53 @@ func New(contractPath string) (micro.Service, map[string]GenericFlag, error) {
 	return service, flagsMap, nil
 }
 
+// NewLogger creates new file logger by contract configuration.
+// The contract name and version are used as the service name and version of the log entries.
+// The LogLevelsFlag value is applied as the sub-logger level overrides.
+func NewLogger(contractPath string, opts ...log.Option) (*log.Logger, error) {
+	contract, err := parseContractFile(contractPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "contract error")
+	}
+
+	if contract.Log == nil {
+		return nil, errors.New("log config is required")
+	}
+
+	opts = append([]log.Option{
+		log.WithFormat(contract.Log.Format),
+		log.WithService(contract.Name, contract.Version),
+	}, opts...)
+
+	logger, err := log.NewFileLogger(contract.Log.Folder, contract.Log.File, logPerm, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if value, ok := contract.flagValue(LogLevelsFlag); ok {
+		if err := applyLevelOverrides(logger, value); err != nil {
+			_ = logger.Close()
+			return nil, errors.Wrapf(err, "could not apply %s flag", LogLevelsFlag)
+		}
+	}
+
+	return logger, nil
+}
+
+// NewReporter creates new error reporter with the provided sinks by contract configuration.
+// The contract name and version are used as the service name and version of the reports.
+func NewReporter(contractPath string, cfg report.Config, sinks ...report.Sink) (*report.Reporter, error) {
+	contract, err := parseContractFile(contractPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "contract error")
+	}
+
+	cfg.Service = contract.Name
+	cfg.Version = contract.Version
+
+	return report.New(cfg, sinks...), nil
+}
+
 func initService(service micro.Service) {
 	_, ok := os.LookupEnv(disableFlagCheckENV)
 	if ok {
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"bytes"
 	"encoding/json"
 	"io/ioutil"
 	"os"
@@ -10,7 +11,12 @@ import (
 
 	"github.com/micro/cli/v2"
 	"github.com/micro/go-micro/v2"
+	"github.com/micro/go-micro/v2/logger"
+	errs "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/common/golang/log"
+	"github.com/open-Q/common/golang/report"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 )
 
@@ -349,9 +355,13 @@ func TestContract_Validate(t *testing.T) {
 		expErr   error
 	}{
 		{
-			name:     "service name is required error",
-			contract: Contract{},
-			expErr:   errors.New("service name is required"),
+			name: "service name is required error",
+			contract: Contract{
+				Config: Config{
+					Host: "127.0.0.1",
+				},
+			},
+			expErr: errors.New("service name is required"),
 		},
 		{
 			name: "service host is required error",
@@ -371,6 +381,11 @@ func TestContract_Validate(t *testing.T) {
 			},
 			expErr: errors.New("flag's name is required"),
 		},
+		{
+			name:     "all the errors",
+			contract: Contract{Flags: []Flag{{}, {Name: "test-flag"}, {}}},
+			expErr:   errors.New("4 errors occurred: service name is required; service host is required; flag's name is required; flag's name is required"),
+		},
 		{
 			name: "all ok",
 			contract: Contract{
@@ -491,7 +506,7 @@ func Test_New(t *testing.T) {
 		}()
 		_, _, err = New(fPath)
 		require.Error(t, err)
-		require.EqualError(t, err, errors.Wrap(errors.New("service name is required"), "validation error").Error())
+		require.EqualError(t, err, errors.Wrap(errors.New("2 errors occurred: service name is required; service host is required"), "validation error").Error())
 	})
 	t.Run("all ok", func(t *testing.T) {
 		contract := Contract{
@@ -533,6 +548,173 @@ func Test_New(t *testing.T) {
 		require.NotNil(t, flagsMap)
 		checkKey(t, flagsMap, "flag-name", "test value")
 	})
+	t.Run("with logger", func(t *testing.T) {
+		contract := Contract{
+			Name: "test",
+			Config: Config{
+				Host: "127.0.0.1",
+			},
+		}
+		data, err := json.Marshal(contract)
+		require.NoError(t, err)
+		fPath := path.Join(os.TempDir(), "temp.json")
+		err = createFileWithContent(fPath, data)
+		require.NoError(t, err)
+		defer func() {
+			err := os.Remove(fPath)
+			require.NoError(t, err)
+		}()
+		defaultLogger := logger.DefaultLogger
+		defer func() {
+			logger.DefaultLogger = defaultLogger
+		}()
+		var buf bytes.Buffer
+		l := log.New(logrus.New())
+		l.SetOutput(&buf)
+		service, _, err := New(fPath, WithLogger(l))
+		require.NoError(t, err)
+		require.NotNil(t, service)
+		require.IsType(t, &log.MicroLogger{}, logger.DefaultLogger)
+		logger.Info("hello micro")
+		require.Contains(t, buf.String(), "hello micro")
+	})
+}
+
+func Test_NewLogger(t *testing.T) {
+	t.Run("parse file error", func(t *testing.T) {
+		_, err := NewLogger("nonexists")
+		require.Error(t, err)
+		require.EqualError(t, err, errors.Wrap(errors.New("could not read nonexists file data: open nonexists: no such file or directory"), "contract error").Error())
+	})
+	t.Run("log config is required error", func(t *testing.T) {
+		fPath := path.Join(os.TempDir(), "temp.json")
+		err := createFileWithContent(fPath, []byte(`{"service":"test"}`))
+		require.NoError(t, err)
+		defer func() {
+			err := os.Remove(fPath)
+			require.NoError(t, err)
+		}()
+		_, err = NewLogger(fPath)
+		require.Error(t, err)
+		require.EqualError(t, err, "log config is required")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		logFolder := path.Join(os.TempDir(), "testlog")
+		contract := Contract{
+			Name:    "test",
+			Version: "0.0.1",
+			Log: &LogConfig{
+				Folder: logFolder,
+				File:   "test.log",
+				Format: "logfmt",
+			},
+		}
+		data, err := json.Marshal(contract)
+		require.NoError(t, err)
+		fPath := path.Join(os.TempDir(), "temp.json")
+		err = createFileWithContent(fPath, data)
+		require.NoError(t, err)
+		defer func() {
+			err := os.Remove(fPath)
+			require.NoError(t, err)
+			err = os.RemoveAll(logFolder)
+			require.NoError(t, err)
+		}()
+		l, err := NewLogger(fPath)
+		require.NoError(t, err)
+		l.Info("hello")
+		err = l.Close()
+		require.NoError(t, err)
+		logData, err := ioutil.ReadFile(path.Join(logFolder, "test.log"))
+		require.NoError(t, err)
+		require.Contains(t, string(logData), "level=info msg=hello service=test version=0.0.1")
+	})
+	t.Run("level overrides", func(t *testing.T) {
+		logFolder := path.Join(os.TempDir(), "testlog")
+		contract := Contract{
+			Name: "test",
+			Log: &LogConfig{
+				Folder: logFolder,
+				File:   "test.log",
+			},
+			Flags: []Flag{
+				{
+					Type:  "string",
+					Name:  LogLevelsFlag,
+					Value: "storage=debug",
+				},
+			},
+		}
+		data, err := json.Marshal(contract)
+		require.NoError(t, err)
+		fPath := path.Join(os.TempDir(), "temp.json")
+		err = createFileWithContent(fPath, data)
+		require.NoError(t, err)
+		defer func() {
+			err := os.Remove(fPath)
+			require.NoError(t, err)
+			err = os.RemoveAll(logFolder)
+			require.NoError(t, err)
+		}()
+		l, err := NewLogger(fPath)
+		require.NoError(t, err)
+		require.Equal(t, logrus.DebugLevel, l.Named("storage").GetLevel())
+		require.Equal(t, logrus.InfoLevel, l.Named("service").GetLevel())
+		err = l.Close()
+		require.NoError(t, err)
+	})
+}
+
+func Test_NewReporter(t *testing.T) {
+	t.Run("parse file error", func(t *testing.T) {
+		_, err := NewReporter("nonexists", report.Config{})
+		require.Error(t, err)
+		require.EqualError(t, err, errors.Wrap(errors.New("could not read nonexists file data: open nonexists: no such file or directory"), "contract error").Error())
+	})
+	t.Run("all ok", func(t *testing.T) {
+		contract := Contract{
+			Name:    "test",
+			Version: "0.0.1",
+			Config: Config{
+				Host: "127.0.0.1",
+			},
+		}
+		data, err := json.Marshal(contract)
+		require.NoError(t, err)
+		fPath := path.Join(os.TempDir(), "temp.json")
+		err = createFileWithContent(fPath, data)
+		require.NoError(t, err)
+		reportPath := path.Join(os.TempDir(), "report.jsonl")
+		defer func() {
+			err := os.Remove(fPath)
+			require.NoError(t, err)
+			err = os.Remove(reportPath)
+			require.NoError(t, err)
+		}()
+		sink, err := report.NewFileSink(reportPath, 0644)
+		require.NoError(t, err)
+		r, err := NewReporter(fPath, report.Config{}, sink)
+		require.NoError(t, err)
+
+		defaultLogger := logger.DefaultLogger
+		defer func() {
+			logger.DefaultLogger = defaultLogger
+		}()
+		l := log.New(logrus.New())
+		l.SetOutput(ioutil.Discard)
+		err = os.Setenv(disableFlagCheckENV, "true")
+		require.NoError(t, err)
+		service, _, err := New(fPath, WithLogger(l), WithReporter(r))
+		require.NoError(t, err)
+		require.NotNil(t, service)
+
+		l.WithError(errs.NewStorageUnknownError("user")).Error("could not get user")
+		err = r.Close()
+		require.NoError(t, err)
+		reportData, err := ioutil.ReadFile(reportPath)
+		require.NoError(t, err)
+		require.Contains(t, string(reportData), `"service":"test","version":"0.0.1"`)
+	})
 }
 
 func createFileWithContent(fName string, data []byte) error {
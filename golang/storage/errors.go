@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"context"
+	"net"
+
+	errs "github.com/open-Q/common/golang/errors"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// There are mongo error codes of the unique index violation.
+const (
+	mongoDuplicateKey       = 11000
+	mongoDuplicateKeyUpdate = 11001
+	mongoDuplicateKeyCapped = 12582
+)
+
+// operation represents the storage operation, which defines the default error type.
+type operation int
+
+// There are storage operations.
+const (
+	opFind operation = iota
+	opInsert
+	opUpdate
+	opDelete
+	opConvert
+)
+
+// There are the fields of the storage errors.
+const (
+	fieldCollection = "collection"
+	fieldOperation  = "operation"
+)
+
+// String returns the operation name.
+func (op operation) String() string {
+	switch op {
+	case opInsert:
+		return "insert"
+	case opUpdate:
+		return "update"
+	case opDelete:
+		return "delete"
+	case opConvert:
+		return "convert"
+	default:
+		return "find"
+	}
+}
+
+// translateError translates the mongo driver error to the typed storage error
+// wrapping the driver error:
+//   - mongo.ErrNoDocuments to StorageFindError matching ErrStorageNotFound;
+//   - duplicate key error to StorageInsertError matching ErrStorageDuplicate;
+//   - timeouts, network and transient errors to StorageUnknownError matching ErrStorageTemporary;
+//   - other errors to the typed error of the operation, the failed find operations,
+//     e.g. invalid query, to StorageUnknownError, so only the missing documents are not found.
+func translateError(op operation, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errs.IsStorageError(err) {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return errs.WrapStorageNotFoundError(err, "")
+	case isDuplicateKeyError(err):
+		return errs.WrapStorageDuplicateError(err, "")
+	case isTemporaryError(err):
+		return errs.WrapStorageTemporaryError(err, "")
+	}
+
+	switch op {
+	case opInsert:
+		return errs.WrapStorageInsertError(err, "")
+	case opUpdate:
+		return errs.WrapStorageUpdateError(err, "")
+	case opDelete:
+		return errs.WrapStorageDeleteError(err, "")
+	case opConvert:
+		return errs.WrapStorageConvertError(err, "")
+	default:
+		return errs.WrapStorageUnknownError(err, "")
+	}
+}
+
+// translateCollectionError translates the mongo driver error like translateError
+// and adds the collection and operation fields to it.
+func translateCollectionError(collection string, op operation, err error) error {
+	return errs.WithFields(translateError(op, err), errs.Fields{
+		fieldCollection: collection,
+		fieldOperation:  op.String(),
+	})
+}
+
+func isDuplicateKeyError(err error) bool {
+	var (
+		writeErr mongo.WriteException
+		bulkErr  mongo.BulkWriteException
+		cmdErr   mongo.CommandError
+	)
+	switch {
+	case errors.As(err, &writeErr):
+		for i := range writeErr.WriteErrors {
+			if isDuplicateKeyCode(writeErr.WriteErrors[i].Code) {
+				return true
+			}
+		}
+	case errors.As(err, &bulkErr):
+		for i := range bulkErr.WriteErrors {
+			if isDuplicateKeyCode(bulkErr.WriteErrors[i].Code) {
+				return true
+			}
+		}
+	case errors.As(err, &cmdErr):
+		return isDuplicateKeyCode(int(cmdErr.Code))
+	}
+	return false
+}
+
+func isDuplicateKeyCode(code int) bool {
+	return code == mongoDuplicateKey || code == mongoDuplicateKeyUpdate || code == mongoDuplicateKeyCapped
+}
+
+func isTemporaryError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, topology.ErrServerSelectionTimeout) {
+		return true
+	}
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.IsMaxTimeMSExpiredError() {
+		return true
+	}
+	// mongo.CommandError, mongo.WriteException and mongo.BulkWriteException have the labels.
+	var labeled interface{ HasErrorLabel(string) bool }
+	if errors.As(err, &labeled) {
+		for _, label := range []string{
+			errs.MongoLabelNetwork, errs.MongoLabelTransientTransaction, errs.MongoLabelRetryableWrite, errs.MongoLabelUnknownCommit,
+		} {
+			if labeled.HasErrorLabel(label) {
+				return true
+			}
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
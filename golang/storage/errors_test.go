@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	errs "github.com/open-Q/common/golang/errors"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Test_translateError(t *testing.T) {
+	duplicateErr := mongo.WriteException{
+		WriteErrors: mongo.WriteErrors{
+			{Code: 11000, Message: "E11000 duplicate key error"},
+		},
+	}
+	tt := []struct {
+		name     string
+		op       operation
+		err      error
+		expIs    []error
+		expAs    interface{}
+		expError string
+	}{
+		{
+			name: "no error",
+			op:   opFind,
+		},
+		{
+			name:     "no documents",
+			op:       opFind,
+			err:      mongo.ErrNoDocuments,
+			expIs:    []error{errs.ErrStorageNotFound, errs.ErrStorageFind, mongo.ErrNoDocuments},
+			expAs:    &errs.StorageFindError{},
+			expError: "could not find value: value not found: mongo: no documents in result",
+		},
+		{
+			name:     "duplicate key",
+			op:       opInsert,
+			err:      duplicateErr,
+			expIs:    []error{errs.ErrStorageDuplicate, errs.ErrStorageInsert},
+			expAs:    &errs.StorageInsertError{},
+			expError: "could not insert value: duplicate value: multiple write errors: [{write errors: [{E11000 duplicate key error}]}, {<nil>}]",
+		},
+		{
+			name:     "duplicate key on update",
+			op:       opUpdate,
+			err:      mongo.CommandError{Code: 11000, Message: "E11000 duplicate key error"},
+			expIs:    []error{errs.ErrStorageDuplicate},
+			expAs:    &errs.StorageInsertError{},
+			expError: "could not insert value: duplicate value: E11000 duplicate key error",
+		},
+		{
+			name:     "timeout",
+			op:       opFind,
+			err:      errors.Wrap(context.DeadlineExceeded, "query"),
+			expIs:    []error{errs.ErrStorageTemporary, errs.ErrStorageUnknown, context.DeadlineExceeded},
+			expAs:    &errs.StorageUnknownError{},
+			expError: "unknown error: temporary failure: query: context deadline exceeded",
+		},
+		{
+			name: "network error",
+			op:   opDelete,
+			err: mongo.CommandError{
+				Message: "connection reset",
+				Labels:  []string{"NetworkError"},
+			},
+			expIs:    []error{errs.ErrStorageTemporary},
+			expAs:    &errs.StorageUnknownError{},
+			expError: "unknown error: temporary failure: connection reset",
+		},
+		{
+			name: "transient transaction error",
+			op:   opInsert,
+			err: mongo.WriteException{
+				WriteErrors: mongo.WriteErrors{
+					{Code: 112, Message: "WriteConflict"},
+				},
+				Labels: []string{"TransientTransactionError"},
+			},
+			expIs:    []error{errs.ErrStorageTemporary},
+			expAs:    &errs.StorageUnknownError{},
+			expError: "unknown error: temporary failure: multiple write errors: [{write errors: [{WriteConflict}]}, {<nil>}]",
+		},
+		{
+			name: "unknown commit result error",
+			op:   opUpdate,
+			err: mongo.CommandError{
+				Message: "commit timed out",
+				Labels:  []string{"UnknownTransactionCommitResult"},
+			},
+			expIs:    []error{errs.ErrStorageTemporary},
+			expAs:    &errs.StorageUnknownError{},
+			expError: "unknown error: temporary failure: commit timed out",
+		},
+		{
+			name:     "net error",
+			op:       opInsert,
+			err:      &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			expIs:    []error{errs.ErrStorageTemporary},
+			expAs:    &errs.StorageUnknownError{},
+			expError: "unknown error: temporary failure: dial tcp: connection refused",
+		},
+		{
+			name:     "update error",
+			op:       opUpdate,
+			err:      mongo.CommandError{Code: 9, Message: "bad update"},
+			expIs:    []error{errs.ErrStorageUpdate},
+			expAs:    &errs.StorageUpdateError{},
+			expError: "could not update value: bad update",
+		},
+		{
+			name:     "find error",
+			op:       opFind,
+			err:      mongo.CommandError{Code: 2, Message: "$where got bad type", Name: "BadValue"},
+			expIs:    []error{errs.ErrStorageUnknown},
+			expAs:    &errs.StorageUnknownError{},
+			expError: "unknown error: (BadValue) $where got bad type",
+		},
+		{
+			name:     "delete error",
+			op:       opDelete,
+			err:      errors.New("some error"),
+			expIs:    []error{errs.ErrStorageDelete},
+			expAs:    &errs.StorageDeleteError{},
+			expError: "could not delete value: some error",
+		},
+		{
+			name:     "convert error",
+			op:       opConvert,
+			err:      bson.Unmarshal([]byte{1}, &struct{}{}),
+			expIs:    []error{errs.ErrStorageConvert},
+			expAs:    &errs.StorageConvertError{},
+			expError: "could not convert value: EOF",
+		},
+	}
+	for i := range tt {
+		tc := &tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			err := translateError(tc.op, tc.err)
+			if tc.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.EqualError(t, err, tc.expError)
+			for _, target := range tc.expIs {
+				require.True(t, errors.Is(err, target), target.Error())
+			}
+			require.True(t, errors.As(err, tc.expAs))
+			require.Equal(t, tc.err, errors.Unwrap(err))
+			// the translated error is not translated again.
+			require.Equal(t, err, translateError(opFind, err))
+		})
+	}
+}
+
+func Test_translateErrorCause(t *testing.T) {
+	err := translateError(opInsert, mongo.WriteException{
+		WriteErrors: mongo.WriteErrors{
+			{Code: 11000, Message: "E11000 duplicate key error"},
+		},
+	})
+	var writeErr mongo.WriteException
+	require.True(t, errors.As(err, &writeErr))
+	require.Equal(t, 11000, writeErr.WriteErrors[0].Code)
+}
+
+func Test_translateCollectionError(t *testing.T) {
+	require.NoError(t, translateCollectionError("users", opFind, nil))
+
+	err := translateCollectionError("users", opDelete, mongo.ErrNoDocuments)
+	require.True(t, errors.Is(err, errs.ErrStorageNotFound))
+	require.EqualError(t, err, "could not find value: value not found: mongo: no documents in result")
+	require.Equal(t, errs.Fields{
+		"collection": "users",
+		"operation":  "delete",
+	}, errs.FieldsOf(err))
+}
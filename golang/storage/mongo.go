@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 
+	errs "github.com/open-Q/common/golang/errors"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -15,10 +16,27 @@ type MongoStorage struct {
 }
 
 // MongoCollection represents mongo collection model.
+// The errors of its operations are translated to the typed storage errors,
+// the original driver errors stay reachable by errors.Unwrap and errors.As.
+// The errors carry the collection and operation fields, see errors.FieldsOf.
+// The temporary errors are classified as retryable and could be retried by retry.Do.
+// The single results and cursors keep the driver types, Result and Cursor translate their errors.
 type MongoCollection struct {
 	*mongo.Collection
 }
 
+// MongoSingleResult represents mongo single result adapter with the typed storage errors.
+type MongoSingleResult struct {
+	*mongo.SingleResult
+	collection string
+}
+
+// MongoCursor represents mongo cursor adapter with the typed storage errors.
+type MongoCursor struct {
+	*mongo.Cursor
+	collection string
+}
+
 // MongoTranscation represents mongo transaction model.
 type MongoTranscation struct {
 	ctx        context.Context
@@ -60,6 +78,133 @@ func (s *MongoStorage) Collection(ctx context.Context, name string, indexes ...m
 	return &MongoCollection{coll}, nil
 }
 
+// InsertOne inserts a single document into the collection.
+func (c *MongoCollection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	res, err := c.Collection.InsertOne(ctx, document, opts...)
+	return res, translateCollectionError(c.Name(), opInsert, err)
+}
+
+// InsertMany inserts the documents into the collection.
+func (c *MongoCollection) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	res, err := c.Collection.InsertMany(ctx, documents, opts...)
+	return res, translateCollectionError(c.Name(), opInsert, err)
+}
+
+// UpdateOne updates a single document in the collection.
+func (c *MongoCollection) UpdateOne(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	res, err := c.Collection.UpdateOne(ctx, filter, update, opts...)
+	return res, translateCollectionError(c.Name(), opUpdate, err)
+}
+
+// UpdateMany updates the documents in the collection.
+func (c *MongoCollection) UpdateMany(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	res, err := c.Collection.UpdateMany(ctx, filter, update, opts...)
+	return res, translateCollectionError(c.Name(), opUpdate, err)
+}
+
+// ReplaceOne replaces a single document in the collection.
+func (c *MongoCollection) ReplaceOne(ctx context.Context, filter, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	res, err := c.Collection.ReplaceOne(ctx, filter, replacement, opts...)
+	return res, translateCollectionError(c.Name(), opUpdate, err)
+}
+
+// DeleteOne deletes a single document from the collection.
+func (c *MongoCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	res, err := c.Collection.DeleteOne(ctx, filter, opts...)
+	return res, translateCollectionError(c.Name(), opDelete, err)
+}
+
+// DeleteMany deletes the documents from the collection.
+func (c *MongoCollection) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	res, err := c.Collection.DeleteMany(ctx, filter, opts...)
+	return res, translateCollectionError(c.Name(), opDelete, err)
+}
+
+// CountDocuments returns the number of the documents in the collection.
+func (c *MongoCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	count, err := c.Collection.CountDocuments(ctx, filter, opts...)
+	return count, translateCollectionError(c.Name(), opFind, err)
+}
+
+// BulkWrite performs the write operations in the collection.
+func (c *MongoCollection) BulkWrite(ctx context.Context, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	res, err := c.Collection.BulkWrite(ctx, models, opts...)
+	return res, translateCollectionError(c.Name(), opUpdate, err)
+}
+
+// Distinct returns the distinct values of the field in the collection.
+func (c *MongoCollection) Distinct(ctx context.Context, fieldName string, filter interface{}, opts ...*options.DistinctOptions) ([]interface{}, error) {
+	values, err := c.Collection.Distinct(ctx, fieldName, filter, opts...)
+	return values, translateCollectionError(c.Name(), opFind, err)
+}
+
+// Find finds the documents in the collection.
+// Use Cursor to get the typed errors of the cursor.
+func (c *MongoCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	cur, err := c.Collection.Find(ctx, filter, opts...)
+	return cur, translateCollectionError(c.Name(), opFind, err)
+}
+
+// Aggregate executes the aggregate command in the collection.
+// Use Cursor to get the typed errors of the cursor.
+func (c *MongoCollection) Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+	cur, err := c.Collection.Aggregate(ctx, pipeline, opts...)
+	return cur, translateCollectionError(c.Name(), opFind, err)
+}
+
+// Watch returns the change stream of the collection.
+func (c *MongoCollection) Watch(ctx context.Context, pipeline interface{}, opts ...*options.ChangeStreamOptions) (*mongo.ChangeStream, error) {
+	stream, err := c.Collection.Watch(ctx, pipeline, opts...)
+	return stream, translateCollectionError(c.Name(), opFind, err)
+}
+
+// Result returns the adapter of the single result of the collection operation,
+// e.g. FindOne or FindOneAndUpdate, which returns the typed storage errors,
+// e.g. coll.Result(coll.FindOne(ctx, filter)).Decode(&v).
+func (c *MongoCollection) Result(res *mongo.SingleResult) *MongoSingleResult {
+	return &MongoSingleResult{res, c.Name()}
+}
+
+// Cursor returns the adapter of the cursor of the collection operation,
+// e.g. Find or Aggregate, which returns the typed storage errors.
+func (c *MongoCollection) Cursor(cur *mongo.Cursor) *MongoCursor {
+	return &MongoCursor{cur, c.Name()}
+}
+
+// Err returns the operation error. A missing document is StorageFindError matching ErrStorageNotFound.
+func (r *MongoSingleResult) Err() error {
+	return translateCollectionError(r.collection, opFind, r.SingleResult.Err())
+}
+
+// Decode decodes the document into v. Decode failures are StorageConvertError.
+func (r *MongoSingleResult) Decode(v interface{}) error {
+	if err := r.Err(); err != nil {
+		return err
+	}
+	return translateCollectionError(r.collection, opConvert, r.SingleResult.Decode(v))
+}
+
+// Err returns the last cursor error.
+func (c *MongoCursor) Err() error {
+	return translateCollectionError(c.collection, opFind, c.Cursor.Err())
+}
+
+// Decode decodes the current document into v. Decode failures are StorageConvertError.
+func (c *MongoCursor) Decode(v interface{}) error {
+	return translateCollectionError(c.collection, opConvert, c.Cursor.Decode(v))
+}
+
+// All decodes all the remaining documents into results and closes the cursor.
+// Decode failures are StorageConvertError.
+func (c *MongoCursor) All(ctx context.Context, results interface{}) error {
+	err := c.Cursor.All(ctx, results)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return translateCollectionError(c.collection, opFind, err)
+	}
+	return translateCollectionError(c.collection, opConvert, err)
+}
+
 // Disconnect closes database connection.
 func (s *MongoStorage) Disconnect(ctx context.Context) error {
 	return s.db.Client().Disconnect(ctx)
@@ -81,12 +226,17 @@ func (t *MongoTranscation) NewOperation(operation func(*mongo.SessionContext) er
 }
 
 // Execute runs transaction and all related operations.
+// The error of the failed operation is returned wrapped with its index, e.g. "operation 1: ...",
+// the abort failure is attached to it as errors.MultiError.
+// The start, abort and commit failures are typed storage errors.
 func (t *MongoTranscation) Execute() error {
 	return t.execTransaction(false)
 }
 
 // ExecuteAsync runs transaction and all related operations.
 // Each operation will be executed in async mode.
+// The errors of all the failed operations are returned as errors.MultiError
+// in the operations order, each wrapped with its index.
 func (t *MongoTranscation) ExecuteAsync() error {
 	return t.execTransaction(true)
 }
@@ -94,43 +244,45 @@ func (t *MongoTranscation) ExecuteAsync() error {
 func (t *MongoTranscation) execTransaction(async bool) error {
 	return t.client.UseSession(t.ctx, func(sc mongo.SessionContext) error {
 		if err := sc.StartTransaction(); err != nil {
-			return err
+			return errors.Wrap(translateError(opUpdate, err), "could not start transaction")
 		}
 
-		errChan := make(chan error, 1)
-
+		var opErr error
 		switch async {
 		case true:
+			// every operation writes its own error, so they are collected in the operations order.
+			opErrs := make([]error, len(t.operations))
 			var wg sync.WaitGroup
 			wg.Add(len(t.operations))
 			for i := range t.operations {
-				go func(operation func(*mongo.SessionContext) error) {
-					err := operation(&sc)
-					if err != nil {
-						errChan <- err
+				go func(i int) {
+					defer wg.Done()
+					if err := t.operations[i](&sc); err != nil {
+						opErrs[i] = errors.Wrapf(err, "operation %d", i)
 					}
-					wg.Done()
-				}(t.operations[i])
+				}(i)
 			}
 			wg.Wait()
-			close(errChan)
+			opErr = errs.Append(nil, opErrs...)
 		default:
-			var err error
 			for i := range t.operations {
-				if err = t.operations[i](&sc); err != nil {
-					errChan <- err
+				if err := t.operations[i](&sc); err != nil {
+					opErr = errors.Wrapf(err, "operation %d", i)
 					break
 				}
 			}
-			if err == nil {
-				close(errChan)
-			}
 		}
 
-		if err, ok := <-errChan; err != nil && ok {
-			return sc.AbortTransaction(t.ctx)
+		if opErr != nil {
+			if err := sc.AbortTransaction(t.ctx); err != nil {
+				return errs.Append(opErr, errors.Wrap(translateError(opUpdate, err), "could not abort transaction"))
+			}
+			return opErr
 		}
 
-		return sc.CommitTransaction(t.ctx)
+		if err := sc.CommitTransaction(t.ctx); err != nil {
+			return errors.Wrap(translateError(opUpdate, err), "could not commit transaction")
+		}
+		return nil
 	})
 }
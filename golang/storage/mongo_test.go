@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
+	"strings"
 	"testing"
 
+	errs "github.com/open-Q/common/golang/errors"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
@@ -59,6 +61,128 @@ func TestMongoStorage_Collection(t *testing.T) {
 	})
 }
 
+func TestMongoCollection_InsertOne(t *testing.T) {
+	ctx := context.Background()
+	db := newTestConnection(t)
+	defer closeTestConnection(t, db)
+	coll, err := db.Collection(ctx, "test-data")
+	require.NoError(t, err)
+
+	id := primitive.NewObjectID()
+	_, err = coll.InsertOne(ctx, bson.M{"_id": id})
+	require.NoError(t, err)
+	_, err = coll.InsertOne(ctx, bson.M{"_id": id})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, errs.ErrStorageDuplicate))
+	var e errs.StorageInsertError
+	require.True(t, errors.As(err, &e))
+	var writeErr mongo.WriteException
+	require.True(t, errors.As(err, &writeErr))
+}
+
+func TestMongoCollection_FindOne(t *testing.T) {
+	t.Run("not found error", func(t *testing.T) {
+		ctx := context.Background()
+		db := newTestConnection(t)
+		defer closeTestConnection(t, db)
+		coll, err := db.Collection(ctx, "test-data")
+		require.NoError(t, err)
+
+		var v bson.M
+		err = coll.Result(coll.FindOne(ctx, bson.M{"_id": primitive.NewObjectID()})).Decode(&v)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, errs.ErrStorageNotFound))
+		require.True(t, errors.Is(err, mongo.ErrNoDocuments))
+	})
+	t.Run("convert error", func(t *testing.T) {
+		ctx := context.Background()
+		db := newTestConnection(t)
+		defer closeTestConnection(t, db)
+		coll, err := db.Collection(ctx, "test-data")
+		require.NoError(t, err)
+
+		id := primitive.NewObjectID()
+		_, err = coll.InsertOne(ctx, bson.M{"_id": id, "name": "john"})
+		require.NoError(t, err)
+		var v struct {
+			Name int `bson:"name"`
+		}
+		err = coll.Result(coll.FindOne(ctx, bson.M{"_id": id})).Decode(&v)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, errs.ErrStorageConvert))
+	})
+}
+
+func TestMongoCollection_Find(t *testing.T) {
+	ctx := context.Background()
+	db := newTestConnection(t)
+	defer closeTestConnection(t, db)
+	coll, err := db.Collection(ctx, "test-data")
+	require.NoError(t, err)
+
+	_, err = coll.InsertOne(ctx, bson.M{"_id": primitive.NewObjectID(), "name": "john"})
+	require.NoError(t, err)
+	cur, err := coll.Find(ctx, bson.M{})
+	require.NoError(t, err)
+	var v []struct {
+		Name int `bson:"name"`
+	}
+	err = coll.Cursor(cur).All(ctx, &v)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, errs.ErrStorageConvert))
+
+	_, err = coll.Find(ctx, bson.M{"$where": 1})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, errs.ErrStorageUnknown))
+	require.False(t, errs.IsNotFound(err))
+}
+
+func TestMongoCollection_BulkWrite(t *testing.T) {
+	ctx := context.Background()
+	db := newTestConnection(t)
+	defer closeTestConnection(t, db)
+	coll, err := db.Collection(ctx, "test-data")
+	require.NoError(t, err)
+
+	id := primitive.NewObjectID()
+	_, err = coll.BulkWrite(ctx, []mongo.WriteModel{
+		mongo.NewInsertOneModel().SetDocument(bson.M{"_id": id}),
+		mongo.NewInsertOneModel().SetDocument(bson.M{"_id": id}),
+	})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, errs.ErrStorageDuplicate))
+	var bulkErr mongo.BulkWriteException
+	require.True(t, errors.As(err, &bulkErr))
+}
+
+func TestMongoCollection_Distinct(t *testing.T) {
+	ctx := context.Background()
+	db := newTestConnection(t)
+	defer closeTestConnection(t, db)
+	coll, err := db.Collection(ctx, "test-data")
+	require.NoError(t, err)
+
+	_, err = coll.Distinct(ctx, "name", bson.M{"$where": 1})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, errs.ErrStorageUnknown))
+	require.False(t, errs.IsNotFound(err))
+	var cmdErr mongo.CommandError
+	require.True(t, errors.As(err, &cmdErr))
+}
+
+func TestMongoCollection_Watch(t *testing.T) {
+	ctx := context.Background()
+	db := newTestConnection(t)
+	defer closeTestConnection(t, db)
+	coll, err := db.Collection(ctx, "test-data")
+	require.NoError(t, err)
+
+	_, err = coll.Watch(ctx, bson.A{bson.M{"$unknown": 1}})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, errs.ErrStorageUnknown))
+	require.False(t, errs.IsNotFound(err))
+}
+
 func TestMongoStorage_Disconnect(t *testing.T) {
 	t.Run("disconnection error", func(t *testing.T) {
 		db := newTestConnection(t)
@@ -116,11 +240,38 @@ func TestMongoTranscation_ExecuteAsync(t *testing.T) {
 				return err
 			}).
 			ExecuteAsync()
-		require.NoError(t, err)
+		require.Error(t, err)
 		count, err := coll.CountDocuments(ctx, bson.D{})
 		require.NoError(t, err)
 		require.Equal(t, int64(0), count)
 	})
+	t.Run("all the operation errors", func(t *testing.T) {
+		ctx := context.Background()
+		db := newTestConnection(t)
+		defer closeTestConnection(t, db)
+
+		err1 := errors.New("error 1")
+		err2 := errors.New("error 2")
+		err := db.NewTranscation(ctx).
+			NewOperation(func(m *mongo.SessionContext) error {
+				return err1
+			}).
+			NewOperation(func(m *mongo.SessionContext) error {
+				return nil
+			}).
+			NewOperation(func(m *mongo.SessionContext) error {
+				return err2
+			}).
+			ExecuteAsync()
+		require.Error(t, err)
+		require.True(t, errors.Is(err, err1))
+		require.True(t, errors.Is(err, err2))
+		var multiErr *errs.MultiError
+		require.True(t, errors.As(err, &multiErr))
+		require.Equal(t, 2, len(multiErr.Errors))
+		require.EqualError(t, multiErr.Errors[0], "operation 0: error 1")
+		require.EqualError(t, multiErr.Errors[1], "operation 2: error 2")
+	})
 	t.Run("all ok", func(t *testing.T) {
 		ctx := context.Background()
 		db := newTestConnection(t)
@@ -168,7 +319,33 @@ func TestMongoTranscation_Execute(t *testing.T) {
 				return err
 			}).
 			Execute()
+		require.Error(t, err)
+		require.True(t, errors.Is(err, errs.ErrStorageDuplicate))
+		require.True(t, strings.HasPrefix(err.Error(), "operation 1: "), err.Error())
+		count, err := coll.CountDocuments(ctx, bson.D{})
 		require.NoError(t, err)
+		require.Equal(t, int64(0), count)
+	})
+	t.Run("operation error", func(t *testing.T) {
+		ctx := context.Background()
+		db := newTestConnection(t)
+		defer closeTestConnection(t, db)
+		coll, err := db.Collection(ctx, "test-data")
+		require.NoError(t, err)
+
+		opErr := errors.New("some error")
+		err = db.NewTranscation(ctx).
+			NewOperation(func(m *mongo.SessionContext) error {
+				_, err := coll.InsertOne(*m, bson.M{"_id": primitive.NewObjectID()})
+				return err
+			}).
+			NewOperation(func(m *mongo.SessionContext) error {
+				return opErr
+			}).
+			Execute()
+		require.Error(t, err)
+		require.True(t, errors.Is(err, opErr))
+		require.EqualError(t, err, "operation 1: some error")
 		count, err := coll.CountDocuments(ctx, bson.D{})
 		require.NoError(t, err)
 		require.Equal(t, int64(0), count)